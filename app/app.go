@@ -18,6 +18,7 @@ import (
 	"github.com/haua/futu/app/drag"
 	"github.com/haua/futu/app/platform"
 	"github.com/haua/futu/app/player"
+	"github.com/haua/futu/app/remote"
 	"github.com/haua/futu/app/utils"
 )
 
@@ -90,16 +91,32 @@ type FloatingWindow struct {
 	modeSwitchMu         sync.Mutex
 	modeHintLabel        *widget.Label
 	modeHintBox          fyne.CanvasObject
+	cropSelector         *drag.CropSelector
+	overlay              *textOverlay
 	modeHintMu           sync.Mutex
 	modeHintTimer        *time.Timer
+	playMu               sync.Mutex
 	imageSourceMu        sync.Mutex
 	imageSourceMode      string
 	fixedImagePath       string
 	randomFolderPath     string
+	folderScan           folderScanOptions
+	folderWatchMu        sync.Mutex
+	folderWatch          *folderWatcher
 	lastRandomImagePath  string
+	remoteListURL        string
+	lastRemoteImagePath  string
+	remoteMu             sync.Mutex
+	remote               *remote.Source
+	remoteCacheDir       string
+	rotationOrder        string
+	shuffleBag           shuffleBag
 	imageTickerStop      chan struct{}
 	imageTickerInterval  time.Duration
+	imageSchedule        *imageSchedule
+	imageScheduleText    string
 	randomIntn           func(int) int
+	onPlaybackChanged    func()
 }
 
 type modeHintTheme struct {
@@ -191,6 +208,13 @@ func NewFloatingWindow(a fyne.App) *FloatingWindow {
 	fw.restoreModeToggleHotkey()
 	fw.restoreHideWindowHotkey()
 	fw.restoreImageSource()
+	fw.restoreImageRotation()
+	fw.restoreRotationOrder()
+	fw.restoreTransition()
+	fw.restoreKenBurns()
+	fw.restoreMask()
+	fw.overlay = newTextOverlay()
+	fw.restoreOverlay()
 	fw.editMode.Store(true)
 	fw.mouseFarOpacity = opacityToAlpha(1)
 
@@ -213,7 +237,17 @@ func NewFloatingWindow(a fyne.App) *FloatingWindow {
 			),
 		),
 	)
-	w.SetContent(container.NewStack(mainContent, hintOverlay))
+	fw.cropSelector = drag.NewCropSelector(fw.applyCropSelection, nil)
+	w.SetContent(container.NewStack(mainContent, fw.overlay.object, fw.cropSelector, hintOverlay))
+	w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		if fw.handleCropKey(ev) {
+			return
+		}
+		if fw.handleTransformKey(ev) {
+			return
+		}
+		fw.handlePlaybackKey(ev)
+	})
 
 	return fw
 }
@@ -270,6 +304,7 @@ func (f *FloatingWindow) ToggleEditMode() bool {
 		}
 		f.applyWindowOpacity(1.0)
 	} else {
+		f.cancelCropSelection()
 		f.startMouseFadeLoop()
 		f.updateWindowOpacityByCursor()
 	}
@@ -505,7 +540,7 @@ func (f *FloatingWindow) updateWindowOpacityByCursor() {
 		return
 	}
 	size := windowSizeInPixels(f.Window)
-	distance := cursorDistanceToRect(cursorPos, winPos, size)
+	distance := f.cursorDistanceToContent(cursorPos, winPos, size)
 	f.applyWindowOpacity(opacityByCursorDistance(distance, f.MouseFarOpacity()))
 }
 
@@ -2,10 +2,13 @@ package app
 
 import (
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"fyne.io/fyne/v2"
 	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
 )
 
 func TestToggleEditMode(t *testing.T) {
@@ -1095,3 +1098,22 @@ func TestRestoreWindowPlacement_MoveFails_FallsBackAndPersistsCentered(t *testin
 		t.Fatalf("fallback saved Y = %v, want 40", got)
 	}
 }
+
+func TestShutdown_ClosesPlayer(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	img := filepath.Join(t.TempDir(), "a.png")
+	if err := os.WriteFile(img, fakeImageData(img), 0o600); err != nil {
+		t.Fatalf("write image: %v", err)
+	}
+
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.Shutdown()
+	fw.playImagePath(img)
+	if got := fw.Player.CurrentPath(); got != "" {
+		t.Fatalf("player should ignore Play after Shutdown, got current path %q", got)
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fyne.io/fyne/v2"
+	"github.com/haua/futu/app/drag"
+	"github.com/haua/futu/app/player"
+)
+
+type cropJSON struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+func cropToJSON(c player.CropRect) *cropJSON {
+	if c.IsFull() {
+		return nil
+	}
+	return &cropJSON{X: c.X, Y: c.Y, W: c.W, H: c.H}
+}
+
+func cropFromJSON(c *cropJSON) player.CropRect {
+	if c == nil {
+		return player.CropRect{}
+	}
+	return player.CropRect{X: c.X, Y: c.Y, W: c.W, H: c.H}
+}
+
+// ImageCrop 返回某张图的裁剪区域，零值表示显示整张图。
+func (f *FloatingWindow) ImageCrop(path string) player.CropRect {
+	return cropFromJSON(f.imageSettingsFor(path).Crop)
+}
+
+// SetImageCrop 保存某张图的裁剪区域，这张图正在播放时立即按新尺寸重播。
+func (f *FloatingWindow) SetImageCrop(path string, c player.CropRect) bool {
+	if !f.updateImageSettings(path, func(settings *imageSettings) {
+		settings.Crop = cropToJSON(c)
+	}) {
+		return false
+	}
+	f.replayIfCurrent(path)
+	return true
+}
+
+// StartCropSelection 在编辑模式下显示裁剪选择层，在当前画面上拖出要保留的区域。
+func (f *FloatingWindow) StartCropSelection() bool {
+	if f == nil || f.cropSelector == nil || f.Player == nil || !f.IsEditMode() {
+		return false
+	}
+	if f.Player.CurrentPath() == "" {
+		return false
+	}
+	f.cropSelector.Start()
+	return true
+}
+
+func (f *FloatingWindow) IsCropSelecting() bool {
+	return f != nil && f.cropSelector != nil && f.cropSelector.Active()
+}
+
+func (f *FloatingWindow) cancelCropSelection() {
+	if f.IsCropSelecting() {
+		f.cropSelector.Cancel()
+	}
+}
+
+// applyCropSelection 把选框换算成相对原图的区域。画面已经裁剪过时，选框是在裁剪后的画面上画的。
+func (f *FloatingWindow) applyCropSelection(sel drag.CropRect) {
+	if f.Player == nil {
+		return
+	}
+	path := f.Player.CurrentPath()
+	if path == "" {
+		return
+	}
+	inner := player.CropRect{X: float64(sel.X), Y: float64(sel.Y), W: float64(sel.W), H: float64(sel.H)}
+	// 画面可能旋转过，选框要先换算回旋转前的方向
+	inner = f.ImageTransform(path).UnmapRect(inner)
+	f.SetImageCrop(path, f.ImageCrop(path).Within(inner))
+}
+
+// ResetImageCrop 让当前图片恢复显示整张图。
+func (f *FloatingWindow) ResetImageCrop() bool {
+	if f == nil || f.Player == nil {
+		return false
+	}
+	path := f.Player.CurrentPath()
+	if path == "" {
+		return false
+	}
+	return f.SetImageCrop(path, player.CropRect{})
+}
+
+// handleCropKey 处理裁剪相关按键：C 开始裁剪，选择中回车确认、Esc 取消。
+func (f *FloatingWindow) handleCropKey(ev *fyne.KeyEvent) bool {
+	if f == nil || ev == nil || !f.IsEditMode() {
+		return false
+	}
+	if f.IsCropSelecting() {
+		switch ev.Name {
+		case fyne.KeyReturn, fyne.KeyEnter:
+			f.cropSelector.Confirm()
+		case fyne.KeyEscape:
+			f.cropSelector.Cancel()
+		}
+		// 选择中不响应其他按键
+		return true
+	}
+	if ev.Name == fyne.KeyC {
+		return f.StartCropSelection()
+	}
+	return false
+}
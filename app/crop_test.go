@@ -0,0 +1,84 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/drag"
+	"github.com/haua/futu/app/player"
+)
+
+func TestCropJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	want := player.CropRect{X: 0.1, Y: 0.2, W: 0.5, H: 0.25}
+	if got := cropFromJSON(cropToJSON(want)); got != want {
+		t.Fatalf("round trip = %+v, want %+v", got, want)
+	}
+	if cropToJSON(player.CropRect{W: 1, H: 1}) != nil {
+		t.Fatalf("full image crop should not be stored")
+	}
+}
+
+func TestCropSelection_PersistsAndComposes(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	path := filepath.Join(t.TempDir(), "a.png")
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.SetImageCrop(path, player.CropRect{X: 0.5, Y: 0, W: 0.5, H: 1})
+
+	reloaded := &FloatingWindow{App: a, Player: fw.Player}
+	want := player.CropRect{X: 0.5, Y: 0, W: 0.5, H: 1}
+	if got := reloaded.ImageCrop(path); got != want {
+		t.Fatalf("reloaded crop = %+v, want %+v", got, want)
+	}
+
+	// 在已裁剪的右半边上再选左半边，结果是原图的第三个四分之一
+	got := reloaded.ImageCrop(path).Within(player.CropRect{X: 0, Y: 0, W: 0.5, H: 1})
+	if want := (player.CropRect{X: 0.5, Y: 0, W: 0.25, H: 1}); got != want {
+		t.Fatalf("composed crop = %+v, want %+v", got, want)
+	}
+
+	fw.applyImageSettings(path)
+	if got := fw.Player.Crop(); got != want {
+		t.Fatalf("player crop = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandleCropKey(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	var confirmed []drag.CropRect
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.cropSelector = drag.NewCropSelector(func(r drag.CropRect) { confirmed = append(confirmed, r) }, nil)
+	fw.editMode.Store(true)
+
+	if fw.handleCropKey(&fyne.KeyEvent{Name: fyne.KeyC}) {
+		t.Fatalf("crop should not start without a current image")
+	}
+	if fw.handleCropKey(&fyne.KeyEvent{Name: fyne.KeySpace}) {
+		t.Fatalf("other keys should pass through when not selecting")
+	}
+
+	fw.cropSelector.Start()
+	if !fw.handleCropKey(&fyne.KeyEvent{Name: fyne.KeySpace}) {
+		t.Fatalf("keys should be swallowed while selecting")
+	}
+	fw.handleCropKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+	if fw.IsCropSelecting() || len(confirmed) != 0 {
+		t.Fatalf("Esc should cancel without confirming")
+	}
+
+	fw.editMode.Store(false)
+	if fw.handleCropKey(&fyne.KeyEvent{Name: fyne.KeyC}) {
+		t.Fatalf("crop keys only work in edit mode")
+	}
+}
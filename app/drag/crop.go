@@ -0,0 +1,166 @@
+package drag
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 选框小于这个尺寸时不确认，避免单击误裁
+const minCropSelection = 8
+
+// CropRect 是选框在选择层中的位置，坐标和宽高都是 0~1 的比例。
+type CropRect struct {
+	X, Y, W, H float32
+}
+
+// CropSelector 盖在图片上，拖动鼠标画出裁剪框。双击或调用 Confirm 确认，右键或 Cancel 取消。
+type CropSelector struct {
+	widget.BaseWidget
+	onConfirm func(CropRect)
+	onCancel  func()
+
+	selecting bool
+	start     fyne.Position
+	end       fyne.Position
+
+	shade  *canvas.Rectangle
+	border *canvas.Rectangle
+}
+
+func NewCropSelector(onConfirm func(CropRect), onCancel func()) *CropSelector {
+	c := &CropSelector{
+		onConfirm: onConfirm,
+		onCancel:  onCancel,
+		shade:     canvas.NewRectangle(color.NRGBA{A: 96}),
+		border:    canvas.NewRectangle(color.NRGBA{R: 255, G: 255, B: 255, A: 48}),
+	}
+	c.border.StrokeColor = color.NRGBA{R: 255, G: 255, B: 255, A: 230}
+	c.border.StrokeWidth = 2
+	c.border.Hide()
+	c.ExtendBaseWidget(c)
+	c.Hide()
+	return c
+}
+
+func (c *CropSelector) CreateRenderer() fyne.WidgetRenderer {
+	return &cropRenderer{selector: c}
+}
+
+// Start 显示选择层并清空之前的选框。
+func (c *CropSelector) Start() {
+	c.selecting = false
+	c.start = fyne.Position{}
+	c.end = fyne.Position{}
+	c.border.Hide()
+	c.Show()
+	c.Refresh()
+}
+
+func (c *CropSelector) Active() bool {
+	return c.Visible()
+}
+
+// Selection 返回当前选框，没有有效选框时 ok 为 false。
+func (c *CropSelector) Selection() (CropRect, bool) {
+	size := c.Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return CropRect{}, false
+	}
+	pos, rect := c.selectionRect()
+	if rect.Width < minCropSelection || rect.Height < minCropSelection {
+		return CropRect{}, false
+	}
+	return CropRect{
+		X: pos.X / size.Width,
+		Y: pos.Y / size.Height,
+		W: rect.Width / size.Width,
+		H: rect.Height / size.Height,
+	}, true
+}
+
+// Confirm 确认当前选框并隐藏选择层，没有有效选框时不做处理。
+func (c *CropSelector) Confirm() bool {
+	rect, ok := c.Selection()
+	if !ok {
+		return false
+	}
+	c.Hide()
+	if c.onConfirm != nil {
+		c.onConfirm(rect)
+	}
+	return true
+}
+
+func (c *CropSelector) Cancel() {
+	if !c.Visible() {
+		return
+	}
+	c.Hide()
+	if c.onCancel != nil {
+		c.onCancel()
+	}
+}
+
+// selectionRect 返回限制在选择层内的选框位置和尺寸。
+func (c *CropSelector) selectionRect() (fyne.Position, fyne.Size) {
+	size := c.Size()
+	clamp := func(p fyne.Position) fyne.Position {
+		return fyne.NewPos(min(max(p.X, 0), size.Width), min(max(p.Y, 0), size.Height))
+	}
+	a, b := clamp(c.start), clamp(c.end)
+	pos := fyne.NewPos(min(a.X, b.X), min(a.Y, b.Y))
+	return pos, fyne.NewSize(max(a.X, b.X)-pos.X, max(a.Y, b.Y)-pos.Y)
+}
+
+func (c *CropSelector) Dragged(ev *fyne.DragEvent) {
+	if !c.selecting {
+		c.selecting = true
+		c.start = ev.Position.Subtract(ev.Dragged)
+		c.border.Show()
+	}
+	c.end = ev.Position
+	c.Refresh()
+}
+
+func (c *CropSelector) DragEnd() {
+	c.selecting = false
+}
+
+func (c *CropSelector) DoubleTapped(*fyne.PointEvent) {
+	c.Confirm()
+}
+
+func (c *CropSelector) TappedSecondary(*fyne.PointEvent) {
+	c.Cancel()
+}
+
+type cropRenderer struct {
+	selector *CropSelector
+}
+
+func (r *cropRenderer) Layout(size fyne.Size) {
+	r.selector.shade.Resize(size)
+	r.selector.shade.Move(fyne.NewPos(0, 0))
+	pos, rect := r.selector.selectionRect()
+	r.selector.border.Move(pos)
+	r.selector.border.Resize(rect)
+}
+
+func (r *cropRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+func (r *cropRenderer) Refresh() {
+	r.Layout(r.selector.Size())
+	r.selector.shade.Refresh()
+	r.selector.border.Refresh()
+}
+
+func (r *cropRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.selector.shade, r.selector.border}
+}
+
+func (r *cropRenderer) Destroy() {}
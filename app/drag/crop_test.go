@@ -0,0 +1,66 @@
+package drag
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func dragSelector(c *CropSelector, from, to fyne.Position) {
+	c.Dragged(&fyne.DragEvent{
+		PointEvent: fyne.PointEvent{Position: to},
+		Dragged:    fyne.Delta{DX: to.X - from.X, DY: to.Y - from.Y},
+	})
+	c.DragEnd()
+}
+
+func TestCropSelector_ConfirmsNormalizedSelection(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+
+	var got []CropRect
+	c := NewCropSelector(func(r CropRect) { got = append(got, r) }, nil)
+	c.Resize(fyne.NewSize(200, 100))
+	if c.Active() {
+		t.Fatalf("selector should start hidden")
+	}
+
+	c.Start()
+	if !c.Active() {
+		t.Fatalf("Start should show the selector")
+	}
+	// 从右下往左上拖，并拖出边界
+	dragSelector(c, fyne.NewPos(150, 75), fyne.NewPos(-20, 25))
+
+	if !c.Confirm() {
+		t.Fatalf("Confirm should accept a valid selection")
+	}
+	want := CropRect{X: 0, Y: 0.25, W: 0.75, H: 0.5}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("confirmed %v, want [%v]", got, want)
+	}
+	if c.Active() {
+		t.Fatalf("selector should hide after confirming")
+	}
+}
+
+func TestCropSelector_IgnoresTinySelectionAndCancels(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+
+	confirmed := false
+	cancelled := false
+	c := NewCropSelector(func(CropRect) { confirmed = true }, func() { cancelled = true })
+	c.Resize(fyne.NewSize(200, 100))
+	c.Start()
+	dragSelector(c, fyne.NewPos(10, 10), fyne.NewPos(12, 13))
+
+	if c.Confirm() || confirmed {
+		t.Fatalf("tiny selection should not be confirmed")
+	}
+	c.TappedSecondary(nil)
+	if !cancelled || c.Active() {
+		t.Fatalf("right click should cancel and hide the selector")
+	}
+}
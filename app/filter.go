@@ -0,0 +1,195 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/haua/futu/app/player"
+)
+
+// 所有图片共用的滤镜，存成 JSON；单张图的滤镜存在 imageSettings 里，优先于这里
+const globalFiltersKey = "image.filters"
+
+type filterSettingsJSON struct {
+	Grayscale    bool    `json:"grayscale,omitempty"`
+	Sepia        bool    `json:"sepia,omitempty"`
+	Blur         float64 `json:"blur,omitempty"`
+	Brightness   float64 `json:"brightness,omitempty"`
+	Contrast     float64 `json:"contrast,omitempty"`
+	Saturation   float64 `json:"saturation,omitempty"`
+	Tint         string  `json:"tint,omitempty"`
+	TintStrength float64 `json:"tint_strength,omitempty"`
+}
+
+var tintPresets = []struct {
+	label string
+	color color.NRGBA
+}{
+	{"无", color.NRGBA{}},
+	{"暖色", color.NRGBA{R: 255, G: 150, B: 60}},
+	{"冷色", color.NRGBA{R: 70, G: 140, B: 255}},
+	{"夜间", color.NRGBA{R: 255, G: 100, B: 0}},
+	{"绿色", color.NRGBA{R: 80, G: 200, B: 120}},
+}
+
+func tintLabel(c color.NRGBA) string {
+	c.A = 0
+	for _, item := range tintPresets {
+		if item.color == c {
+			return item.label
+		}
+	}
+	return formatTintColor(c)
+}
+
+func tintFromLabel(label string) color.NRGBA {
+	for _, item := range tintPresets {
+		if item.label == label {
+			return item.color
+		}
+	}
+	c, _ := parseTintColor(label)
+	return c
+}
+
+func formatTintColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func parseTintColor(s string) (color.NRGBA, bool) {
+	var c color.NRGBA
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return c, false
+	}
+	if _, err := fmt.Sscanf(s, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return color.NRGBA{}, false
+	}
+	return c, true
+}
+
+func filtersToJSON(s player.FilterSettings) *filterSettingsJSON {
+	if s.IsIdentity() {
+		return nil
+	}
+	out := &filterSettingsJSON{
+		Grayscale:  s.Grayscale,
+		Sepia:      s.Sepia,
+		Blur:       s.Blur,
+		Brightness: s.Brightness,
+		Contrast:   s.Contrast,
+		Saturation: s.Saturation,
+	}
+	if s.Tint.A > 0 {
+		out.Tint = formatTintColor(s.Tint)
+		out.TintStrength = float64(s.Tint.A) / 255
+	}
+	return out
+}
+
+func filtersFromJSON(s *filterSettingsJSON) player.FilterSettings {
+	if s == nil {
+		return player.FilterSettings{}
+	}
+	out := player.FilterSettings{
+		Grayscale:  s.Grayscale,
+		Sepia:      s.Sepia,
+		Blur:       s.Blur,
+		Brightness: s.Brightness,
+		Contrast:   s.Contrast,
+		Saturation: s.Saturation,
+	}
+	if c, ok := parseTintColor(s.Tint); ok && s.TintStrength > 0 {
+		c.A = uint8(min(s.TintStrength, 1) * 255)
+		out.Tint = c
+	}
+	return out
+}
+
+func (f *FloatingWindow) GlobalFilters() player.FilterSettings {
+	if f == nil || f.App == nil {
+		return player.FilterSettings{}
+	}
+	raw := strings.TrimSpace(f.App.Preferences().String(globalFiltersKey))
+	if raw == "" {
+		return player.FilterSettings{}
+	}
+	var s filterSettingsJSON
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		log.Printf("load filters failed: %v", err)
+		return player.FilterSettings{}
+	}
+	return filtersFromJSON(&s)
+}
+
+// SetGlobalFilters 保存所有图片共用的滤镜，当前图片没有单独滤镜时立即生效。
+func (f *FloatingWindow) SetGlobalFilters(s player.FilterSettings) {
+	if f == nil || f.App == nil {
+		return
+	}
+	raw := ""
+	if js := filtersToJSON(s); js != nil {
+		data, err := json.Marshal(js)
+		if err != nil {
+			log.Printf("save filters failed: %v", err)
+			return
+		}
+		raw = string(data)
+	}
+	f.App.Preferences().SetString(globalFiltersKey, raw)
+	f.refreshCurrentFilters()
+}
+
+// ImageFilters 返回某张图的单独滤镜，ok 为 false 表示这张图跟随全局滤镜。
+func (f *FloatingWindow) ImageFilters(path string) (player.FilterSettings, bool) {
+	s := f.imageSettingsFor(path)
+	return filtersFromJSON(s.Filters), s.Filters != nil
+}
+
+// SetImageFilters 保存某张图的单独滤镜，s 为 nil 时改回跟随全局滤镜。
+func (f *FloatingWindow) SetImageFilters(path string, s *player.FilterSettings) bool {
+	if !f.updateImageSettings(path, func(settings *imageSettings) {
+		settings.Filters = nil
+		if s != nil {
+			// 单独设置成不加滤镜也要记下来，不能退回全局滤镜
+			settings.Filters = filtersToJSON(*s)
+			if settings.Filters == nil {
+				settings.Filters = &filterSettingsJSON{}
+			}
+		}
+	}) {
+		return false
+	}
+	f.refreshCurrentFilters()
+	return true
+}
+
+func (f *FloatingWindow) filtersFor(path string) player.FilterSettings {
+	if s, ok := f.ImageFilters(path); ok {
+		return s
+	}
+	return f.GlobalFilters()
+}
+
+// refreshCurrentFilters 让当前图片按最新的滤镜设置重新渲染。
+func (f *FloatingWindow) refreshCurrentFilters() {
+	if f.Player == nil {
+		return
+	}
+	f.playMu.Lock()
+	path := f.Player.CurrentPath()
+	if path != "" {
+		f.Player.SetFilters(f.filtersFor(path))
+	}
+	f.playMu.Unlock()
+	if path == "" {
+		return
+	}
+	// 平移缩放的画面在开始时就处理好了，要重播才能换滤镜
+	if f.Player.KenBurns().Enabled {
+		f.replayIfCurrent(path)
+	}
+}
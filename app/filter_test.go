@@ -0,0 +1,87 @@
+package app
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestFiltersJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []player.FilterSettings{
+		{Grayscale: true},
+		{Sepia: true, Blur: 2.5},
+		{Brightness: -0.4, Contrast: 0.2, Saturation: -0.5},
+		{Tint: color.NRGBA{R: 255, G: 150, B: 60, A: 102}},
+	}
+	for _, want := range tests {
+		if got := filtersFromJSON(filtersToJSON(want)); got != want {
+			t.Fatalf("round trip = %+v, want %+v", got, want)
+		}
+	}
+	if filtersToJSON(player.FilterSettings{}) != nil {
+		t.Fatalf("identity filters should not be stored")
+	}
+	if got := filtersFromJSON(&filterSettingsJSON{Tint: "bogus", TintStrength: 1}); got != (player.FilterSettings{}) {
+		t.Fatalf("invalid tint should be ignored, got %+v", got)
+	}
+}
+
+func TestTintLabels(t *testing.T) {
+	t.Parallel()
+
+	for _, item := range tintPresets {
+		if got := tintFromLabel(tintLabel(item.color)); got != item.color {
+			t.Fatalf("tint label round trip of %q = %+v", item.label, got)
+		}
+	}
+	custom := color.NRGBA{R: 1, G: 2, B: 3}
+	if got := tintFromLabel(tintLabel(custom)); got != custom {
+		t.Fatalf("custom tint round trip = %+v, want %+v", got, custom)
+	}
+}
+
+func TestImageFilters_OverrideGlobal(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.png")
+	second := filepath.Join(dir, "b.png")
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+
+	global := player.FilterSettings{Brightness: -0.5}
+	fw.SetGlobalFilters(global)
+	none := player.FilterSettings{}
+	if !fw.SetImageFilters(first, &none) {
+		t.Fatalf("SetImageFilters should succeed")
+	}
+
+	fw.applyImageSettings(first)
+	if got := fw.Player.Filters(); got != none {
+		t.Fatalf("first image filters = %+v, want its own override", got)
+	}
+	fw.applyImageSettings(second)
+	if got := fw.Player.Filters(); got != global {
+		t.Fatalf("second image filters = %+v, want global %+v", got, global)
+	}
+
+	reloaded := &FloatingWindow{App: a}
+	if _, ok := reloaded.ImageFilters(first); !ok {
+		t.Fatalf("per-image override should survive reload")
+	}
+	if got := reloaded.GlobalFilters(); got != global {
+		t.Fatalf("reloaded global filters = %+v, want %+v", got, global)
+	}
+
+	fw.SetImageFilters(first, nil)
+	if _, ok := fw.ImageFilters(first); ok {
+		t.Fatalf("clearing the override should fall back to global filters")
+	}
+}
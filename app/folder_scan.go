@@ -0,0 +1,226 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	folderRecursivePrefKey = "image.folder_recursive"
+	folderMaxDepthPrefKey  = "image.folder_max_depth"
+	folderPatternsPrefKey  = "image.folder_patterns"
+
+	defaultFolderMaxDepth = 5
+	maxFolderMaxDepth     = 32
+)
+
+// folderScanOptions 是文件夹模式的扫描方式。Patterns 每条一个 glob，以 ! 开头的是排除规则，
+// 有包含规则时只保留匹配的文件。路径相对于所选文件夹，用 / 分隔，不区分大小写。
+type folderScanOptions struct {
+	Recursive bool
+	// MaxDepth 是最多往下找几层子文件夹，只在 Recursive 时有效
+	MaxDepth int
+	Patterns []string
+}
+
+func normalizeFolderScanOptions(opts folderScanOptions) folderScanOptions {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultFolderMaxDepth
+	}
+	opts.MaxDepth = min(opts.MaxDepth, maxFolderMaxDepth)
+	opts.Patterns = parseFolderPatterns(strings.Join(opts.Patterns, "\n"))
+	return opts
+}
+
+// parseFolderPatterns 按行拆分规则，去掉空行。
+func parseFolderPatterns(text string) []string {
+	var patterns []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "!" {
+			continue
+		}
+		patterns = append(patterns, filepath.ToSlash(line))
+	}
+	return patterns
+}
+
+func validateFolderPatterns(patterns []string) error {
+	for _, p := range patterns {
+		for _, seg := range strings.Split(strings.TrimPrefix(p, "!"), "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("%q: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchFolderGlob 判断相对路径是否匹配 glob。** 匹配任意层目录；不含 / 的规则只匹配文件名。
+func matchFolderGlob(pattern, rel string) bool {
+	pattern = strings.ToLower(strings.Trim(pattern, "/"))
+	rel = strings.ToLower(rel)
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// folderPatternsAllow 按包含、排除规则过滤，排除优先。
+func folderPatternsAllow(patterns []string, rel string) bool {
+	included, hasInclude := false, false
+	for _, p := range patterns {
+		if exclude, ok := strings.CutPrefix(p, "!"); ok {
+			if matchFolderGlob(exclude, rel) {
+				return false
+			}
+			continue
+		}
+		hasInclude = true
+		if !included && matchFolderGlob(p, rel) {
+			included = true
+		}
+	}
+	return included || !hasInclude
+}
+
+// scanImageFolder 列出文件夹里可播放的图片。递归时跟随指向文件夹的快捷方式（符号链接），
+// 同一个真实目录只进一次，避免链接成环时死循环。
+func scanImageFolder(dir string, opts folderScanOptions) ([]string, error) {
+	files, _, err := walkImageFolder(dir, opts)
+	return files, err
+}
+
+// walkImageFolder 同时返回扫描到的图片和进入过的文件夹，监听文件夹变化时要用到后者。
+func walkImageFolder(dir string, opts folderScanOptions) ([]string, []string, error) {
+	opts = normalizeFolderScanOptions(opts)
+	visited := make(map[string]struct{})
+	var files, dirs []string
+
+	var walk func(current, rel string, depth int) error
+	walk = func(current, rel string, depth int) error {
+		if real, err := filepath.EvalSymlinks(current); err == nil {
+			if _, ok := visited[real]; ok {
+				return nil
+			}
+			visited[real] = struct{}{}
+		}
+		entries, err := os.ReadDir(current)
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, current)
+		for _, entry := range entries {
+			full := filepath.Join(current, entry.Name())
+			relPath := path.Join(rel, entry.Name())
+			isDir := entry.IsDir()
+			if entry.Type()&os.ModeSymlink != 0 {
+				if info, err := os.Stat(full); err == nil {
+					isDir = info.IsDir()
+				}
+			}
+			if isDir {
+				if opts.Recursive && depth < opts.MaxDepth {
+					// 子文件夹读不了时跳过，不影响其他图片
+					_ = walk(full, relPath, depth+1)
+				}
+				continue
+			}
+			if !folderPatternsAllow(opts.Patterns, relPath) || !isSupportedImagePath(full) {
+				continue
+			}
+			files = append(files, full)
+		}
+		return nil
+	}
+
+	if err := walk(dir, "", 0); err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(files)
+	return files, dirs, nil
+}
+
+func (o folderScanOptions) equal(other folderScanOptions) bool {
+	o, other = normalizeFolderScanOptions(o), normalizeFolderScanOptions(other)
+	return o.Recursive == other.Recursive && o.MaxDepth == other.MaxDepth &&
+		strings.Join(o.Patterns, "\n") == strings.Join(other.Patterns, "\n")
+}
+
+func (f *FloatingWindow) FolderScanOptions() folderScanOptions {
+	if f == nil {
+		return normalizeFolderScanOptions(folderScanOptions{})
+	}
+	f.imageSourceMu.Lock()
+	defer f.imageSourceMu.Unlock()
+	return normalizeFolderScanOptions(f.folderScan)
+}
+
+// SetFolderScanOptions 保存扫描方式，文件夹模式下立即按新规则换一张图。
+// 规则写错或新规则下没有图片时返回错误，不保存。
+func (f *FloatingWindow) SetFolderScanOptions(opts folderScanOptions) error {
+	if f == nil {
+		return nil
+	}
+	opts = normalizeFolderScanOptions(opts)
+	if err := validateFolderPatterns(opts.Patterns); err != nil {
+		return err
+	}
+
+	f.imageSourceMu.Lock()
+	mode := normalizeImageSourceMode(f.imageSourceMode)
+	dir := strings.TrimSpace(f.randomFolderPath)
+	f.imageSourceMu.Unlock()
+	if dir != "" {
+		if candidates, err := scanImageFolder(dir, opts); err != nil || len(candidates) == 0 {
+			return errors.New("没有符合条件的图片")
+		}
+	}
+
+	f.imageSourceMu.Lock()
+	f.folderScan = opts
+	f.saveImageSourceLocked()
+	f.imageSourceMu.Unlock()
+
+	f.syncFolderWatch()
+	if mode == imageSourceModeFolder && dir != "" {
+		f.playRandomFromFolder(dir)
+	}
+	return nil
+}
+
+// listFolderImages 优先用监听维护的索引，没有在监听这个文件夹时现场扫描。
+func (f *FloatingWindow) listFolderImages(dir string) ([]string, error) {
+	opts := f.FolderScanOptions()
+	if files, ok := f.folderWatchIndex(dir, opts); ok {
+		return files, nil
+	}
+	return scanImageFolder(dir, opts)
+}
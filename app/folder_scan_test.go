@@ -0,0 +1,168 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestMatchFolderGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"**/calm/**", "calm/a.png", true},
+		{"**/calm/**", "x/y/calm/z/a.png", true},
+		{"**/calm/**", "calmer/a.png", false},
+		{"*.gif", "deep/dir/a.GIF", true},
+		{"*.gif", "a.png", false},
+		{"cats/*.png", "cats/a.png", true},
+		{"cats/*.png", "cats/sub/a.png", false},
+		{"**/*.png", "a.png", true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.pattern+" "+tt.rel, func(t *testing.T) {
+			t.Parallel()
+			if got := matchFolderGlob(tt.pattern, tt.rel); got != tt.want {
+				t.Fatalf("matchFolderGlob(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFolderPatternsAllow(t *testing.T) {
+	t.Parallel()
+
+	patterns := parseFolderPatterns("**/calm/**\r\n\n  !**/drafts/**  \n")
+	if !reflect.DeepEqual(patterns, []string{"**/calm/**", "!**/drafts/**"}) {
+		t.Fatalf("parseFolderPatterns = %q", patterns)
+	}
+	if !folderPatternsAllow(patterns, "calm/a.png") {
+		t.Fatalf("included file should pass")
+	}
+	if folderPatternsAllow(patterns, "calm/drafts/a.png") {
+		t.Fatalf("exclude should win over include")
+	}
+	if folderPatternsAllow(patterns, "busy/a.png") {
+		t.Fatalf("file outside include patterns should be skipped")
+	}
+	if !folderPatternsAllow([]string{"!*.gif"}, "busy/a.png") {
+		t.Fatalf("only exclude patterns should keep other files")
+	}
+	if err := validateFolderPatterns([]string{"[a-"}); err == nil {
+		t.Fatalf("broken pattern should fail validation")
+	}
+}
+
+func makeImageTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"top.png", "calm/a.png", "calm/drafts/b.png", "calm/deep/er/c.png", "busy/d.gif"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, fakeImageData(path), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func relPaths(t *testing.T, dir string, files []string) []string {
+	t.Helper()
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		rel, err := filepath.Rel(dir, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	return out
+}
+
+func TestScanImageFolder(t *testing.T) {
+	t.Parallel()
+
+	dir := makeImageTree(t)
+	tests := []struct {
+		name string
+		opts folderScanOptions
+		want []string
+	}{
+		{"top level only", folderScanOptions{}, []string{"top.png"}},
+		{"depth one", folderScanOptions{Recursive: true, MaxDepth: 1}, []string{"busy/d.gif", "calm/a.png", "top.png"}},
+		{"default depth", folderScanOptions{Recursive: true}, []string{"busy/d.gif", "calm/a.png", "calm/deep/er/c.png", "calm/drafts/b.png", "top.png"}},
+		{"patterns", folderScanOptions{Recursive: true, Patterns: []string{"**/calm/**", "!**/drafts/**"}}, []string{"calm/a.png", "calm/deep/er/c.png"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := scanImageFolder(dir, tt.opts)
+			if err != nil {
+				t.Fatalf("scanImageFolder: %v", err)
+			}
+			if rel := relPaths(t, dir, got); !reflect.DeepEqual(rel, tt.want) {
+				t.Fatalf("scanImageFolder = %q, want %q", rel, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanImageFolder_SymlinkLoop(t *testing.T) {
+	t.Parallel()
+
+	dir := makeImageTree(t)
+	if err := os.Symlink(dir, filepath.Join(dir, "calm", "loop")); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+	got, err := scanImageFolder(dir, folderScanOptions{Recursive: true, MaxDepth: maxFolderMaxDepth})
+	if err != nil {
+		t.Fatalf("scanImageFolder: %v", err)
+	}
+	if len(got) != 5 {
+		t.Fatalf("symlink loop should not duplicate files, got %q", relPaths(t, dir, got))
+	}
+}
+
+func TestSetFolderScanOptions_PersistAndRestore(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+
+	dir := makeImageTree(t)
+	fw := &FloatingWindow{App: a}
+	fw.restoreImageSource()
+	fw.imageSourceMu.Lock()
+	fw.randomFolderPath = dir
+	fw.imageSourceMu.Unlock()
+
+	if err := fw.SetFolderScanOptions(folderScanOptions{Patterns: []string{"**/calm/**"}}); err == nil {
+		t.Fatalf("options without matching images should be rejected")
+	}
+	want := folderScanOptions{Recursive: true, MaxDepth: 2, Patterns: []string{"**/calm/**", "!**/drafts/**"}}
+	if err := fw.SetFolderScanOptions(want); err != nil {
+		t.Fatalf("SetFolderScanOptions: %v", err)
+	}
+
+	restored := &FloatingWindow{App: a}
+	restored.restoreImageSource()
+	if got := restored.FolderScanOptions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("restored options = %+v, want %+v", got, want)
+	}
+	got, err := restored.listFolderImages(dir)
+	if err != nil {
+		t.Fatalf("listFolderImages: %v", err)
+	}
+	if rel := relPaths(t, dir, got); !reflect.DeepEqual(rel, []string{"calm/a.png"}) {
+		t.Fatalf("listFolderImages = %q", rel)
+	}
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	showNewImagesPrefKey = "image.show_new_images"
+	// 复制大文件、编辑器保存时会连续触发很多事件，停下来一会儿再重新扫描
+	folderWatchDebounce = 300 * time.Millisecond
+)
+
+// folderWatcher 监听图片文件夹，变化后重新扫描并维护候选图片的索引。
+type folderWatcher struct {
+	dir  string
+	opts folderScanOptions
+
+	watcher  *fsnotify.Watcher
+	onChange func(added, removed []string)
+	stop     chan struct{}
+	done     chan struct{}
+
+	mu      sync.Mutex
+	files   []string
+	watched map[string]struct{}
+}
+
+func newFolderWatcher(dir string, opts folderScanOptions, onChange func(added, removed []string)) (*folderWatcher, error) {
+	files, dirs, err := walkImageFolder(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &folderWatcher{
+		dir:      dir,
+		opts:     opts,
+		watcher:  watcher,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		files:    files,
+		watched:  make(map[string]struct{}),
+	}
+	w.watchDirs(dirs)
+	go w.run()
+	return w, nil
+}
+
+// watchDirs 监听新出现的文件夹。fsnotify 不会递归，子文件夹要逐个添加，删掉的文件夹会自动移除。
+func (w *folderWatcher) watchDirs(dirs []string) {
+	for _, d := range dirs {
+		if _, ok := w.watched[d]; ok {
+			continue
+		}
+		if err := w.watcher.Add(d); err != nil {
+			log.Printf("watch folder %q failed: %v", d, err)
+			continue
+		}
+		w.watched[d] = struct{}{}
+	}
+}
+
+func (w *folderWatcher) run() {
+	defer close(w.done)
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Write) {
+				continue
+			}
+			if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+				w.mu.Lock()
+				delete(w.watched, ev.Name)
+				w.mu.Unlock()
+			}
+			if timer == nil {
+				timer = time.NewTimer(folderWatchDebounce)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(folderWatchDebounce)
+			}
+			fire = timer.C
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watch folder failed: %v", err)
+		case <-fire:
+			fire = nil
+			w.rescan()
+		case <-w.stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// rescan 重新扫描文件夹，把新增和删除的图片告诉 onChange。
+func (w *folderWatcher) rescan() {
+	files, dirs, err := walkImageFolder(w.dir, w.opts)
+	if err != nil {
+		// 文件夹本身被删掉时，索引清空，当前图片按删除处理
+		log.Printf("rescan folder %q failed: %v", w.dir, err)
+		files = nil
+	}
+
+	w.mu.Lock()
+	w.watchDirs(dirs)
+	old := w.files
+	w.files = files
+	w.mu.Unlock()
+
+	added, removed := diffSortedPaths(old, files)
+	if (len(added) > 0 || len(removed) > 0) && w.onChange != nil {
+		w.onChange(added, removed)
+	}
+}
+
+func (w *folderWatcher) index() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.files...)
+}
+
+func (w *folderWatcher) close() {
+	close(w.stop)
+	<-w.done
+	if err := w.watcher.Close(); err != nil {
+		log.Printf("close folder watcher failed: %v", err)
+	}
+}
+
+// diffSortedPaths 比较两个已排序的路径列表。
+func diffSortedPaths(old, cur []string) (added, removed []string) {
+	i, j := 0, 0
+	for i < len(old) || j < len(cur) {
+		switch {
+		case j >= len(cur) || (i < len(old) && old[i] < cur[j]):
+			removed = append(removed, old[i])
+			i++
+		case i >= len(old) || cur[j] < old[i]:
+			added = append(added, cur[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return added, removed
+}
+
+// syncFolderWatch 让监听和当前播放来源保持一致：文件夹模式下监听所选文件夹，其他情况停止监听。
+func (f *FloatingWindow) syncFolderWatch() {
+	if f == nil {
+		return
+	}
+	f.imageSourceMu.Lock()
+	mode := normalizeImageSourceMode(f.imageSourceMode)
+	dir := strings.TrimSpace(f.randomFolderPath)
+	opts := normalizeFolderScanOptions(f.folderScan)
+	f.imageSourceMu.Unlock()
+
+	f.folderWatchMu.Lock()
+	current := f.folderWatch
+	want := mode == imageSourceModeFolder && dir != ""
+	if current != nil && want && current.dir == dir && current.opts.equal(opts) {
+		f.folderWatchMu.Unlock()
+		return
+	}
+	f.folderWatch = nil
+	f.folderWatchMu.Unlock()
+
+	// 在锁外关闭，回调里换图时还会读索引
+	if current != nil {
+		current.close()
+	}
+	if !want {
+		return
+	}
+	w, err := newFolderWatcher(dir, opts, func(added, removed []string) {
+		f.onFolderChanged(dir, added, removed)
+	})
+	if err != nil {
+		// 监听失败时退回到每次换图时扫描
+		log.Printf("watch image folder failed: %v", err)
+		return
+	}
+	f.folderWatchMu.Lock()
+	old := f.folderWatch
+	f.folderWatch = w
+	f.folderWatchMu.Unlock()
+	if old != nil {
+		old.close()
+	}
+}
+
+func (f *FloatingWindow) stopFolderWatch() {
+	if f == nil {
+		return
+	}
+	f.folderWatchMu.Lock()
+	w := f.folderWatch
+	f.folderWatch = nil
+	f.folderWatchMu.Unlock()
+	if w != nil {
+		w.close()
+	}
+}
+
+// folderWatchIndex 返回监听中维护的候选图片，没有在按同样的规则监听 dir 时 ok 为 false。
+func (f *FloatingWindow) folderWatchIndex(dir string, opts folderScanOptions) ([]string, bool) {
+	if f == nil {
+		return nil, false
+	}
+	f.folderWatchMu.Lock()
+	w := f.folderWatch
+	f.folderWatchMu.Unlock()
+	if w == nil || w.dir != dir || !w.opts.equal(opts) {
+		return nil, false
+	}
+	return w.index(), true
+}
+
+// onFolderChanged 当前图片被删除或改名时换一张；开启了立即显示新图时，显示新加入的图。
+func (f *FloatingWindow) onFolderChanged(dir string, added, removed []string) {
+	f.imageSourceMu.Lock()
+	active := normalizeImageSourceMode(f.imageSourceMode) == imageSourceModeFolder && strings.TrimSpace(f.randomFolderPath) == dir
+	f.imageSourceMu.Unlock()
+	if !active {
+		return
+	}
+
+	current := ""
+	if f.Player != nil {
+		current = f.Player.CurrentPath()
+	}
+	for _, p := range removed {
+		if p == current {
+			f.playRandomFromFolder(dir)
+			return
+		}
+	}
+	if len(added) > 0 && f.ShowNewImages() {
+		f.showFolderImage(added[len(added)-1])
+	}
+}
+
+// showFolderImage 直接显示文件夹里的某张图，洗牌时记作本轮已显示。
+func (f *FloatingWindow) showFolderImage(path string) {
+	f.imageSourceMu.Lock()
+	f.lastRandomImagePath = path
+	if !slices.Contains(f.shuffleBag.Remaining, path) && !slices.Contains(f.shuffleBag.Shown, path) {
+		f.shuffleBag.Shown = append(f.shuffleBag.Shown, path)
+	}
+	f.saveRotationLocked()
+	f.imageSourceMu.Unlock()
+
+	f.playImagePath(path)
+}
+
+func (f *FloatingWindow) ShowNewImages() bool {
+	if f == nil || f.App == nil {
+		return false
+	}
+	return f.App.Preferences().Bool(showNewImagesPrefKey)
+}
+
+func (f *FloatingWindow) SetShowNewImages(enabled bool) {
+	if f == nil || f.App == nil {
+		return
+	}
+	f.App.Preferences().SetBool(showNewImagesPrefKey, enabled)
+}
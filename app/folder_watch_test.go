@@ -0,0 +1,153 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestDiffSortedPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		old, cur     []string
+		added, remov []string
+	}{
+		{"same", []string{"a", "b"}, []string{"a", "b"}, nil, nil},
+		{"added", []string{"b"}, []string{"a", "b", "c"}, []string{"a", "c"}, nil},
+		{"removed", []string{"a", "b", "c"}, []string{"b"}, nil, []string{"a", "c"}},
+		{"renamed", []string{"a", "c"}, []string{"b", "c"}, []string{"b"}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			added, removed := diffSortedPaths(tt.old, tt.cur)
+			if !reflect.DeepEqual(added, tt.added) || !reflect.DeepEqual(removed, tt.remov) {
+				t.Fatalf("diffSortedPaths = %q, %q, want %q, %q", added, removed, tt.added, tt.remov)
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func writeImageFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, fakeImageData(path), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFolderWatcher_TracksNestedChanges(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeImageFile(t, filepath.Join(dir, "a.png"))
+
+	var mu sync.Mutex
+	var added, removed []string
+	w, err := newFolderWatcher(dir, folderScanOptions{Recursive: true}, func(a, r []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		added = append(added, a...)
+		removed = append(removed, r...)
+	})
+	if err != nil {
+		t.Fatalf("newFolderWatcher: %v", err)
+	}
+	t.Cleanup(w.close)
+
+	// 先建子文件夹，等它被监听后再往里放图
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "sub folder watched", func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		_, ok := w.watched[sub]
+		return ok
+	})
+	nested := filepath.Join(sub, "b.png")
+	writeImageFile(t, nested)
+	waitFor(t, "nested image indexed", func() bool { return len(w.index()) == 2 })
+
+	if err := os.Remove(filepath.Join(dir, "a.png")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "removed image dropped", func() bool {
+		return reflect.DeepEqual(w.index(), []string{nested})
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(added, []string{nested}) || !reflect.DeepEqual(removed, []string{filepath.Join(dir, "a.png")}) {
+		t.Fatalf("onChange got added %q removed %q", added, removed)
+	}
+}
+
+func TestFolderWatch_FollowsCurrentImage(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.png")
+	second := filepath.Join(dir, "b.png")
+	writeImageFile(t, first)
+	writeImageFile(t, second)
+
+	p := player.NewPlayer(a, w)
+	t.Cleanup(p.Close)
+	fw := &FloatingWindow{App: a, Player: p, imageTickerInterval: 24 * time.Hour}
+	fw.SetRotationOrder(rotationOrderName)
+	t.Cleanup(fw.stopFolderWatch)
+	t.Cleanup(fw.stopImageTicker)
+	if !fw.SetRandomImageFolder(dir) {
+		t.Fatalf("SetRandomImageFolder should succeed")
+	}
+	if p.CurrentPath() != first {
+		t.Fatalf("current = %q, want %q", p.CurrentPath(), first)
+	}
+	if files, ok := fw.folderWatchIndex(dir, fw.FolderScanOptions()); !ok || len(files) != 2 {
+		t.Fatalf("folder index = %q, %v", files, ok)
+	}
+
+	// 当前图片被删掉时换下一张
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "moving on from the deleted image", func() bool { return p.CurrentPath() == second })
+
+	// 开启后新图片立即显示
+	fw.SetShowNewImages(true)
+	added := filepath.Join(dir, "c.png")
+	writeImageFile(t, added)
+	waitFor(t, "showing the new image", func() bool { return p.CurrentPath() == added })
+
+	fw.SetImageSourceMode(imageSourceModeSingle)
+	if _, ok := fw.folderWatchIndex(dir, fw.FolderScanOptions()); ok {
+		t.Fatalf("leaving folder mode should stop watching")
+	}
+}
@@ -591,6 +591,11 @@ func (f *FloatingWindow) Shutdown() {
 	}
 	f.stopMouseFadeLoop()
 	f.stopImageTicker()
+	f.stopFolderWatch()
+	f.stopOverlay()
+	if f.Player != nil {
+		f.Player.Close()
+	}
 	if f.hotkeyUnregister != nil {
 		f.hotkeyUnregister()
 	}
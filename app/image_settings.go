@@ -0,0 +1,198 @@
+package app
+
+import (
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/haua/futu/app/player"
+)
+
+// 每张图单独的播放设置，以图片路径为键存成一个 JSON
+const perImageSettingsKey = "image.per_image_settings"
+
+const (
+	loopModeAuthored = "authored"
+	loopModeForever  = "forever"
+	loopModeTimes    = "times"
+	loopModePingPong = "pingpong"
+	loopEndLast      = "last"
+	loopEndFirst     = "first"
+)
+
+type imageSettings struct {
+	Loop      *loopSettingsJSON   `json:"loop,omitempty"`
+	Filters   *filterSettingsJSON `json:"filters,omitempty"`
+	Crop      *cropJSON           `json:"crop,omitempty"`
+	Transform *transformJSON      `json:"transform,omitempty"`
+}
+
+type loopSettingsJSON struct {
+	Mode  string `json:"mode"`
+	Count int    `json:"count,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+func (s imageSettings) isEmpty() bool {
+	return s.Loop == nil && s.Filters == nil && s.Crop == nil && s.Transform == nil
+}
+
+func loopSettingsToJSON(s player.LoopSettings) *loopSettingsJSON {
+	if s == (player.LoopSettings{}) {
+		return nil
+	}
+	out := &loopSettingsJSON{Mode: loopModeAuthored, End: loopEndLast}
+	switch s.Mode {
+	case player.LoopForever:
+		out.Mode = loopModeForever
+	case player.LoopTimes:
+		out.Mode = loopModeTimes
+		out.Count = s.Count
+	case player.LoopPingPong:
+		out.Mode = loopModePingPong
+		out.Count = s.Count
+	}
+	if s.End == player.LoopEndFirst {
+		out.End = loopEndFirst
+	}
+	return out
+}
+
+func loopSettingsFromJSON(s *loopSettingsJSON) player.LoopSettings {
+	var out player.LoopSettings
+	if s == nil {
+		return out
+	}
+	switch strings.ToLower(strings.TrimSpace(s.Mode)) {
+	case loopModeForever:
+		out.Mode = player.LoopForever
+	case loopModeTimes:
+		out.Mode = player.LoopTimes
+		out.Count = max(s.Count, 1)
+	case loopModePingPong:
+		out.Mode = player.LoopPingPong
+		out.Count = max(s.Count, 0)
+	}
+	if strings.ToLower(strings.TrimSpace(s.End)) == loopEndFirst {
+		out.End = player.LoopEndFirst
+	}
+	return out
+}
+
+func imageSettingsKey(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Clean(path)
+}
+
+func (f *FloatingWindow) loadImageSettingsLocked() map[string]imageSettings {
+	out := make(map[string]imageSettings)
+	if f.App == nil {
+		return out
+	}
+	raw := strings.TrimSpace(f.App.Preferences().String(perImageSettingsKey))
+	if raw == "" {
+		return out
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		log.Printf("load per-image settings failed: %v", err)
+		return make(map[string]imageSettings)
+	}
+	return out
+}
+
+func (f *FloatingWindow) saveImageSettingsLocked(all map[string]imageSettings) {
+	if f.App == nil {
+		return
+	}
+	if len(all) == 0 {
+		f.App.Preferences().SetString(perImageSettingsKey, "")
+		return
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		log.Printf("save per-image settings failed: %v", err)
+		return
+	}
+	f.App.Preferences().SetString(perImageSettingsKey, string(data))
+}
+
+func (f *FloatingWindow) imageSettingsFor(path string) imageSettings {
+	key := imageSettingsKey(path)
+	if f == nil || key == "" {
+		return imageSettings{}
+	}
+	f.imageSourceMu.Lock()
+	defer f.imageSourceMu.Unlock()
+	return f.loadImageSettingsLocked()[key]
+}
+
+// updateImageSettings 修改一张图的设置并保存，设置全为默认值时删除这张图的记录。
+func (f *FloatingWindow) updateImageSettings(path string, update func(*imageSettings)) bool {
+	key := imageSettingsKey(path)
+	if f == nil || key == "" {
+		return false
+	}
+
+	f.imageSourceMu.Lock()
+	all := f.loadImageSettingsLocked()
+	s := all[key]
+	update(&s)
+	if s.isEmpty() {
+		delete(all, key)
+	} else {
+		all[key] = s
+	}
+	f.saveImageSettingsLocked(all)
+	f.imageSourceMu.Unlock()
+	return true
+}
+
+// ImageLoopSettings 返回某张图的循环设置，没有单独设置时返回零值（按文件设置播放）。
+func (f *FloatingWindow) ImageLoopSettings(path string) player.LoopSettings {
+	return loopSettingsFromJSON(f.imageSettingsFor(path).Loop)
+}
+
+// SetImageLoopSettings 保存某张图的循环设置，这张图正在播放时立即按新设置重播。
+func (f *FloatingWindow) SetImageLoopSettings(path string, s player.LoopSettings) bool {
+	if !f.updateImageSettings(path, func(settings *imageSettings) {
+		settings.Loop = loopSettingsToJSON(s)
+	}) {
+		return false
+	}
+	f.replayIfCurrent(path)
+	return true
+}
+
+func (f *FloatingWindow) replayIfCurrent(path string) {
+	if f.Player == nil {
+		return
+	}
+	current := f.Player.CurrentPath()
+	if current == "" || imageSettingsKey(current) != imageSettingsKey(path) {
+		return
+	}
+	f.playImagePath(current)
+}
+
+// applyImageSettings 在播放前把这张图的单独设置交给播放器。
+func (f *FloatingWindow) applyImageSettings(path string) {
+	if f == nil || f.Player == nil {
+		return
+	}
+	s := f.imageSettingsFor(path)
+	f.Player.SetLoopSettings(loopSettingsFromJSON(s.Loop))
+	f.Player.SetCrop(cropFromJSON(s.Crop))
+	f.Player.SetTransform(transformFromJSON(s.Transform))
+	if s.Filters != nil {
+		f.Player.SetFilters(filtersFromJSON(s.Filters))
+	} else {
+		f.Player.SetFilters(f.GlobalFilters())
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestLoopSettingsJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []player.LoopSettings{
+		{Mode: player.LoopForever},
+		{Mode: player.LoopTimes, Count: 3, End: player.LoopEndFirst},
+		{Mode: player.LoopPingPong, Count: 2},
+		{Mode: player.LoopPingPong},
+		{End: player.LoopEndFirst},
+	}
+
+	for _, want := range tests {
+		if got := loopSettingsFromJSON(loopSettingsToJSON(want)); got != want {
+			t.Fatalf("round trip = %+v, want %+v", got, want)
+		}
+	}
+	if loopSettingsToJSON(player.LoopSettings{}) != nil {
+		t.Fatalf("default loop settings should not be stored")
+	}
+	if got := loopSettingsFromJSON(&loopSettingsJSON{Mode: loopModeTimes}); got.Count != 1 {
+		t.Fatalf("times without count should play once, got %d", got.Count)
+	}
+}
+
+func TestSetImageLoopSettings_PersistsPerImage(t *testing.T) {
+	t.Parallel()
+
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.gif")
+	second := filepath.Join(dir, "b.gif")
+	want := player.LoopSettings{Mode: player.LoopTimes, Count: 1, End: player.LoopEndFirst}
+
+	fw := &FloatingWindow{App: a}
+	if !fw.SetImageLoopSettings(first, want) {
+		t.Fatalf("SetImageLoopSettings should succeed")
+	}
+	if got := fw.ImageLoopSettings(first); got != want {
+		t.Fatalf("ImageLoopSettings(first) = %+v, want %+v", got, want)
+	}
+	if got := fw.ImageLoopSettings(second); got != (player.LoopSettings{}) {
+		t.Fatalf("ImageLoopSettings(second) = %+v, want default", got)
+	}
+
+	reloaded := &FloatingWindow{App: a}
+	if got := reloaded.ImageLoopSettings(first); got != want {
+		t.Fatalf("reloaded settings = %+v, want %+v", got, want)
+	}
+
+	if !fw.SetImageLoopSettings(first, player.LoopSettings{}) {
+		t.Fatalf("resetting loop settings should succeed")
+	}
+	if got := a.Preferences().String(perImageSettingsKey); got != "" {
+		t.Fatalf("default settings should remove the stored entry, got %q", got)
+	}
+}
+
+func TestApplyImageSettings_SetsPlayerLoop(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	path := filepath.Join(t.TempDir(), "a.gif")
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	want := player.LoopSettings{Mode: player.LoopPingPong, Count: 2}
+	fw.SetImageLoopSettings(path, want)
+
+	fw.applyImageSettings(path)
+	if got := fw.Player.LoopSettings(); got != want {
+		t.Fatalf("player loop = %+v, want %+v", got, want)
+	}
+	fw.applyImageSettings(filepath.Join(filepath.Dir(path), "other.gif"))
+	if got := fw.Player.LoopSettings(); got != (player.LoopSettings{}) {
+		t.Fatalf("player loop for other image = %+v, want default", got)
+	}
+}
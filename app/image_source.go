@@ -2,10 +2,10 @@ package app
 
 import (
 	"os"
-	"path/filepath"
-	"sort"
 	"strings"
 	"time"
+
+	"github.com/haua/futu/app/player"
 )
 
 const (
@@ -20,48 +20,22 @@ func normalizeImageSourceMode(mode string) string {
 	switch strings.ToLower(strings.TrimSpace(mode)) {
 	case imageSourceModeFolder:
 		return imageSourceModeFolder
+	case imageSourceModeRemote:
+		return imageSourceModeRemote
 	default:
 		return imageSourceModeSingle
 	}
 }
 
-func supportedImageExtSet() map[string]struct{} {
-	_, allow := imageFileFilters()
-	out := make(map[string]struct{}, len(allow))
-	for _, ext := range allow {
-		out[strings.ToLower(strings.TrimSpace(ext))] = struct{}{}
-	}
-	return out
-}
-
+// isSupportedImagePath 判断能否播放，精灵图的描述文件跟着图片走，本身不算图片。
+// 按内容识别格式，每个文件第一次检查时要打开读文件头，之后文件没改动就用缓存的结果。
 func isSupportedImagePath(path string) bool {
-	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
-	if ext == "" {
-		return false
-	}
-	_, ok := supportedImageExtSet()[ext]
-	return ok
+	return !player.IsSpriteSheetSidecar(path) && player.IsSupportedImageFile(path)
 }
 
+// listSupportedImageFiles 只列出文件夹第一层的图片。
 func listSupportedImageFiles(dir string) ([]string, error) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
-
-	files := make([]string, 0, len(entries))
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		name := entry.Name()
-		if !isSupportedImagePath(name) {
-			continue
-		}
-		files = append(files, filepath.Join(dir, name))
-	}
-	sort.Strings(files)
-	return files, nil
+	return scanImageFolder(dir, folderScanOptions{})
 }
 
 func pickRandomImagePath(candidates []string, last string, randIntn func(int) int) string {
@@ -120,23 +94,36 @@ func (f *FloatingWindow) restoreImageSource() {
 	mode := imageSourceModeSingle
 	fixedPath := ""
 	folderPath := ""
+	remoteURL := ""
+	var scan folderScanOptions
 	if f.App != nil {
 		prefs := f.App.Preferences()
 		mode = normalizeImageSourceMode(prefs.String(imageSourceModeKey))
 		fixedPath = strings.TrimSpace(prefs.String(fixedImagePathPrefKey))
 		folderPath = strings.TrimSpace(prefs.String(randomFolderPathPrefKey))
+		remoteURL = strings.TrimSpace(prefs.String(remoteListURLPrefKey))
 		if fixedPath == "" {
 			fixedPath = strings.TrimSpace(prefs.String("player.last_image_path"))
 		}
+		scan = folderScanOptions{
+			Recursive: prefs.Bool(folderRecursivePrefKey),
+			MaxDepth:  prefs.Int(folderMaxDepthPrefKey),
+			Patterns:  parseFolderPatterns(prefs.String(folderPatternsPrefKey)),
+		}
 	}
 	if mode == imageSourceModeFolder && folderPath == "" {
 		mode = imageSourceModeSingle
 	}
+	if mode == imageSourceModeRemote && remoteURL == "" {
+		mode = imageSourceModeSingle
+	}
 
 	f.imageSourceMu.Lock()
 	f.imageSourceMode = mode
 	f.fixedImagePath = fixedPath
 	f.randomFolderPath = folderPath
+	f.remoteListURL = remoteURL
+	f.folderScan = normalizeFolderScanOptions(scan)
 	f.lastRandomImagePath = ""
 	f.imageSourceMu.Unlock()
 }
@@ -149,6 +136,11 @@ func (f *FloatingWindow) saveImageSourceLocked() {
 	prefs.SetString(imageSourceModeKey, normalizeImageSourceMode(f.imageSourceMode))
 	prefs.SetString(fixedImagePathPrefKey, strings.TrimSpace(f.fixedImagePath))
 	prefs.SetString(randomFolderPathPrefKey, strings.TrimSpace(f.randomFolderPath))
+	prefs.SetString(remoteListURLPrefKey, strings.TrimSpace(f.remoteListURL))
+	scan := normalizeFolderScanOptions(f.folderScan)
+	prefs.SetBool(folderRecursivePrefKey, scan.Recursive)
+	prefs.SetInt(folderMaxDepthPrefKey, scan.MaxDepth)
+	prefs.SetString(folderPatternsPrefKey, strings.Join(scan.Patterns, "\n"))
 }
 
 func (f *FloatingWindow) playImageOnStartup() {
@@ -160,6 +152,7 @@ func (f *FloatingWindow) playImageOnStartup() {
 	mode := normalizeImageSourceMode(f.imageSourceMode)
 	fixedPath := strings.TrimSpace(f.fixedImagePath)
 	folderPath := strings.TrimSpace(f.randomFolderPath)
+	remoteURL := strings.TrimSpace(f.remoteListURL)
 	f.imageSourceMu.Unlock()
 
 	if mode == imageSourceModeFolder && folderPath != "" {
@@ -168,6 +161,12 @@ func (f *FloatingWindow) playImageOnStartup() {
 			return
 		}
 	}
+	if mode == imageSourceModeRemote && remoteURL != "" {
+		// 下载可能很慢，不阻塞启动；没有网络时用缓存的图
+		f.startImageTicker()
+		go f.playFromRemote()
+		return
+	}
 
 	f.stopImageTicker()
 	if fixedPath != "" {
@@ -177,7 +176,10 @@ func (f *FloatingWindow) playImageOnStartup() {
 		}
 	}
 	if f.Player != nil {
+		f.playMu.Lock()
+		f.applyImageSettings(f.Player.LastPath())
 		f.Player.PlayLast()
+		f.playMu.Unlock()
 	}
 }
 
@@ -191,7 +193,7 @@ func (f *FloatingWindow) SetImageSourceMode(mode string) bool {
 	oldMode := normalizeImageSourceMode(f.imageSourceMode)
 	folderPath := strings.TrimSpace(f.randomFolderPath)
 	fixedPath := strings.TrimSpace(f.fixedImagePath)
-	if mode == imageSourceModeFolder && folderPath == "" {
+	if (mode == imageSourceModeFolder && folderPath == "") || (mode == imageSourceModeRemote && strings.TrimSpace(f.remoteListURL) == "") {
 		f.imageSourceMu.Unlock()
 		return false
 	}
@@ -210,6 +212,11 @@ func (f *FloatingWindow) SetImageSourceMode(mode string) bool {
 		f.startImageTicker()
 		return true
 	}
+	if mode == imageSourceModeRemote {
+		f.startImageTicker()
+		go f.playFromRemote()
+		return true
+	}
 
 	f.stopImageTicker()
 	if fixedPath != "" {
@@ -254,7 +261,7 @@ func (f *FloatingWindow) SetRandomImageFolder(dir string) bool {
 	if err != nil || !info.IsDir() {
 		return false
 	}
-	candidates, err := listSupportedImageFiles(dir)
+	candidates, err := f.listFolderImages(dir)
 	if err != nil || len(candidates) == 0 {
 		return false
 	}
@@ -280,6 +287,11 @@ func (f *FloatingWindow) PlayRandomImageNow() bool {
 	mode := normalizeImageSourceMode(f.imageSourceMode)
 	dir := strings.TrimSpace(f.randomFolderPath)
 	f.imageSourceMu.Unlock()
+	if mode == imageSourceModeRemote {
+		// 可能要现场下载，不能卡住界面线程
+		go f.playFromRemote()
+		return true
+	}
 	if dir == "" {
 		return false
 	}
@@ -294,29 +306,25 @@ func (f *FloatingWindow) playImagePath(path string) {
 	if f == nil || f.Player == nil {
 		return
 	}
+	// 单图设置写在共用的播放器里，和 Play 一起加锁：
+	// 定时器、文件夹监听、网络下载和界面可能同时换图，不能让一张图用上另一张图的设置
+	f.playMu.Lock()
+	defer f.playMu.Unlock()
+	f.applyImageSettings(path)
 	f.Player.Play(path)
 }
 
 func (f *FloatingWindow) playRandomFromFolder(dir string) bool {
-	candidates, err := listSupportedImageFiles(dir)
+	candidates, err := f.listFolderImages(dir)
 	if err != nil || len(candidates) == 0 {
 		return false
 	}
 
-	f.imageSourceMu.Lock()
-	last := f.lastRandomImagePath
-	randIntn := f.randomIntn
-	f.imageSourceMu.Unlock()
-
-	picked := pickRandomImagePath(candidates, last, randIntn)
+	picked := f.pickFolderImage(candidates)
 	if picked == "" {
 		return false
 	}
 	f.playImagePath(picked)
-
-	f.imageSourceMu.Lock()
-	f.lastRandomImagePath = picked
-	f.imageSourceMu.Unlock()
 	return true
 }
 
@@ -330,27 +338,29 @@ func (f *FloatingWindow) startImageTicker() {
 		f.imageSourceMu.Unlock()
 		return
 	}
-	interval := f.imageTickerInterval
-	if interval <= 0 {
-		interval = imageTickInterval
-	}
 	stop := make(chan struct{})
 	f.imageTickerStop = stop
 	f.imageSourceMu.Unlock()
+	f.syncFolderWatch()
 
 	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
+		// 每次换图后按当前设置重新算下一次，设置改变时会重启计时
+		timer := time.NewTimer(f.nextImageTickDelay(time.Now()))
+		defer timer.Stop()
 		for {
 			select {
-			case <-ticker.C:
+			case <-timer.C:
 				f.imageSourceMu.Lock()
 				mode := normalizeImageSourceMode(f.imageSourceMode)
 				dir := strings.TrimSpace(f.randomFolderPath)
 				f.imageSourceMu.Unlock()
-				if mode == imageSourceModeFolder && dir != "" {
+				switch {
+				case mode == imageSourceModeFolder && dir != "":
 					_ = f.playRandomFromFolder(dir)
+				case mode == imageSourceModeRemote:
+					_ = f.playFromRemote()
 				}
+				timer.Reset(f.nextImageTickDelay(time.Now()))
 			case <-stop:
 				return
 			}
@@ -370,4 +380,5 @@ func (f *FloatingWindow) stopImageTicker() {
 	if stop != nil {
 		close(stop)
 	}
+	f.syncFolderWatch()
 }
@@ -3,12 +3,26 @@ package app
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	fynetest "fyne.io/fyne/v2/test"
 )
 
+// fakeImageData 返回和扩展名对应的文件头，格式按内容识别，只写扩展名不算图片
+func fakeImageData(name string) []byte {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png":
+		return []byte("\x89PNG\r\n\x1a\n")
+	case ".jpg", ".jpeg":
+		return []byte{0xff, 0xd8, 0xff, 0xe0}
+	case ".gif":
+		return []byte("GIF89a")
+	}
+	return []byte("x")
+}
+
 func TestNormalizeImageSourceMode(t *testing.T) {
 	t.Parallel()
 
@@ -29,7 +43,7 @@ func TestListSupportedImageFiles(t *testing.T) {
 	dir := t.TempDir()
 	makeFile := func(name string) {
 		t.Helper()
-		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600); err != nil {
+		if err := os.WriteFile(filepath.Join(dir, name), fakeImageData(name), 0o600); err != nil {
 			t.Fatalf("write file %q: %v", name, err)
 		}
 	}
@@ -51,6 +65,26 @@ func TestListSupportedImageFiles(t *testing.T) {
 	}
 }
 
+func TestListSupportedImageFiles_SniffsContent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "saved-by-browser"), []byte("GIF89a"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	got, err := listSupportedImageFiles(dir)
+	if err != nil {
+		t.Fatalf("listSupportedImageFiles: %v", err)
+	}
+	if len(got) != 1 || filepath.Base(got[0]) != "saved-by-browser" {
+		t.Fatalf("supported files = %v, want only saved-by-browser", got)
+	}
+}
+
 func TestPickRandomImagePath_AvoidsImmediateRepeat(t *testing.T) {
 	t.Parallel()
 
@@ -68,7 +102,7 @@ func TestSetFixedImage(t *testing.T) {
 	t.Cleanup(a.Quit)
 
 	img := filepath.Join(t.TempDir(), "fixed.png")
-	if err := os.WriteFile(img, []byte("x"), 0o600); err != nil {
+	if err := os.WriteFile(img, fakeImageData(img), 0o600); err != nil {
 		t.Fatalf("write fixed image: %v", err)
 	}
 
@@ -94,7 +128,7 @@ func TestSetRandomImageFolder(t *testing.T) {
 	t.Cleanup(a.Quit)
 
 	dir := t.TempDir()
-	if err := os.WriteFile(filepath.Join(dir, "a.png"), []byte("x"), 0o600); err != nil {
+	if err := os.WriteFile(filepath.Join(dir, "a.png"), fakeImageData("a.png"), 0o600); err != nil {
 		t.Fatalf("write random image: %v", err)
 	}
 
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"time"
+
+	"github.com/haua/futu/app/player"
+)
+
+const (
+	kenBurnsEnabledKey   = "image.ken_burns"
+	kenBurnsIntensityKey = "image.ken_burns_intensity"
+	kenBurnsCycleSecKey  = "image.ken_burns_cycle_s"
+)
+
+// restoreKenBurns 读取静态图平移缩放设置，默认关闭。
+func (f *FloatingWindow) restoreKenBurns() {
+	if f == nil || f.App == nil || f.Player == nil {
+		return
+	}
+	prefs := f.App.Preferences()
+	f.Player.SetKenBurns(player.KenBurnsSettings{
+		Enabled:   prefs.Bool(kenBurnsEnabledKey),
+		Intensity: prefs.Float(kenBurnsIntensityKey),
+		Cycle:     time.Duration(prefs.Int(kenBurnsCycleSecKey)) * time.Second,
+	})
+}
+
+func (f *FloatingWindow) KenBurnsSettings() player.KenBurnsSettings {
+	if f == nil || f.Player == nil {
+		return player.KenBurnsSettings{}
+	}
+	return f.Player.KenBurns()
+}
+
+// SetKenBurnsSettings 保存设置，并重新播放当前图片让设置立即生效。
+func (f *FloatingWindow) SetKenBurnsSettings(s player.KenBurnsSettings) {
+	if f == nil || f.Player == nil {
+		return
+	}
+	f.Player.SetKenBurns(s)
+	s = f.Player.KenBurns()
+	if f.App != nil {
+		prefs := f.App.Preferences()
+		prefs.SetBool(kenBurnsEnabledKey, s.Enabled)
+		prefs.SetFloat(kenBurnsIntensityKey, s.Intensity)
+		prefs.SetInt(kenBurnsCycleSecKey, int(s.Cycle/time.Second))
+	}
+	f.replayIfCurrent(f.Player.CurrentPath())
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestKenBurnsSettings_PersistAndRestore(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.restoreKenBurns()
+	if got := fw.KenBurnsSettings(); got.Enabled || got.Intensity != player.DefaultKenBurnsIntensity || got.Cycle != player.DefaultKenBurnsCycle {
+		t.Fatalf("default ken burns = %+v, want disabled with defaults", got)
+	}
+
+	fw.SetKenBurnsSettings(player.KenBurnsSettings{Enabled: true, Intensity: 0.3, Cycle: 42 * time.Second})
+
+	restored := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	restored.restoreKenBurns()
+	want := player.KenBurnsSettings{Enabled: true, Intensity: 0.3, Cycle: 42 * time.Second}
+	if got := restored.KenBurnsSettings(); got != want {
+		t.Fatalf("restored ken burns = %+v, want %+v", got, want)
+	}
+}
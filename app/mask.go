@@ -0,0 +1,117 @@
+package app
+
+import (
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/haua/futu/app/player"
+)
+
+const (
+	maskKindKey   = "image.mask"
+	maskRadiusKey = "image.mask_radius"
+	maskPathKey   = "image.mask_path"
+
+	defaultMaskRadius = 0.1
+)
+
+var maskNames = []struct {
+	kind  player.MaskKind
+	name  string
+	label string
+}{
+	{player.MaskNone, "none", "无"},
+	{player.MaskRoundedRect, "rounded", "圆角"},
+	{player.MaskCircle, "circle", "圆形"},
+	{player.MaskEllipse, "ellipse", "椭圆"},
+	{player.MaskImage, "image", "自定义 PNG"},
+}
+
+func maskKindFromName(name string) player.MaskKind {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, item := range maskNames {
+		if item.name == name {
+			return item.kind
+		}
+	}
+	return player.MaskNone
+}
+
+func maskKindName(kind player.MaskKind) string {
+	for _, item := range maskNames {
+		if item.kind == kind {
+			return item.name
+		}
+	}
+	return maskNames[0].name
+}
+
+func maskKindLabel(kind player.MaskKind) string {
+	for _, item := range maskNames {
+		if item.kind == kind {
+			return item.label
+		}
+	}
+	return maskNames[0].label
+}
+
+func maskKindFromLabel(label string) player.MaskKind {
+	for _, item := range maskNames {
+		if item.label == label {
+			return item.kind
+		}
+	}
+	return player.MaskNone
+}
+
+// restoreMask 读取画面遮罩设置，自定义遮罩图不可用时不加遮罩。
+func (f *FloatingWindow) restoreMask() {
+	if f == nil || f.App == nil || f.Player == nil {
+		return
+	}
+	prefs := f.App.Preferences()
+	s := player.MaskSettings{
+		Kind:   maskKindFromName(prefs.String(maskKindKey)),
+		Radius: prefs.FloatWithFallback(maskRadiusKey, defaultMaskRadius),
+		Path:   strings.TrimSpace(prefs.String(maskPathKey)),
+	}
+	if err := f.Player.SetMask(s); err != nil {
+		log.Printf("restore mask failed: %v", err)
+	}
+}
+
+func (f *FloatingWindow) MaskSettings() player.MaskSettings {
+	if f == nil || f.Player == nil {
+		return player.MaskSettings{}
+	}
+	return f.Player.Mask()
+}
+
+// SetMaskSettings 应用并保存画面遮罩，自定义遮罩图加载失败时返回错误，设置不变。
+func (f *FloatingWindow) SetMaskSettings(s player.MaskSettings) error {
+	if f == nil || f.Player == nil {
+		return nil
+	}
+	if err := f.Player.SetMask(s); err != nil {
+		return err
+	}
+	if f.App != nil {
+		prefs := f.App.Preferences()
+		prefs.SetString(maskKindKey, maskKindName(s.Kind))
+		prefs.SetFloat(maskRadiusKey, s.Radius)
+		prefs.SetString(maskPathKey, s.Path)
+	}
+	f.resetFadeState()
+	return nil
+}
+
+// cursorDistanceToContent 计算鼠标到遮罩后画面形状的距离，没有遮罩时就是到窗口矩形的距离。
+func (f *FloatingWindow) cursorDistanceToContent(cursor, winPos fyne.Position, size fyne.Size) float32 {
+	if f.Player != nil {
+		if d, ok := f.Player.DistanceToContent(cursor.X-winPos.X, cursor.Y-winPos.Y, size.Width, size.Height); ok {
+			return d
+		}
+	}
+	return cursorDistanceToRect(cursor, winPos, size)
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestMaskKindNames(t *testing.T) {
+	t.Parallel()
+
+	for _, item := range maskNames {
+		if got := maskKindFromName(maskKindName(item.kind)); got != item.kind {
+			t.Fatalf("round trip of %q = %v", item.name, got)
+		}
+		if got := maskKindFromLabel(maskKindLabel(item.kind)); got != item.kind {
+			t.Fatalf("label round trip of %q = %v", item.label, got)
+		}
+	}
+	if got := maskKindFromName("bogus"); got != player.MaskNone {
+		t.Fatalf("unknown mask name should mean no mask, got %v", got)
+	}
+}
+
+func TestMaskSettings_PersistAndRestore(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.restoreMask()
+	if got := fw.MaskSettings(); got != (player.MaskSettings{}) {
+		t.Fatalf("default mask = %+v, want none", got)
+	}
+
+	want := player.MaskSettings{Kind: player.MaskRoundedRect, Radius: 0.25}
+	if err := fw.SetMaskSettings(want); err != nil {
+		t.Fatalf("SetMaskSettings: %v", err)
+	}
+	if err := fw.SetMaskSettings(player.MaskSettings{Kind: player.MaskImage, Path: filepath.Join(t.TempDir(), "missing.png")}); err == nil {
+		t.Fatalf("missing mask image should fail")
+	}
+
+	restored := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	restored.restoreMask()
+	if got := restored.MaskSettings(); got != want {
+		t.Fatalf("restored mask = %+v, want %+v", got, want)
+	}
+}
+
+func TestCursorDistanceToContent_UsesMaskShape(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	pos := fyne.NewPos(100, 200)
+	size := fyne.NewSize(100, 100)
+	corner := fyne.NewPos(101, 201)
+	if got := fw.cursorDistanceToContent(corner, pos, size); got != 0 {
+		t.Fatalf("without a mask the corner is inside the window, got %v", got)
+	}
+
+	if err := fw.SetMaskSettings(player.MaskSettings{Kind: player.MaskCircle}); err != nil {
+		t.Fatalf("SetMaskSettings: %v", err)
+	}
+	if got := fw.cursorDistanceToContent(corner, pos, size); got < 19 || got > 20 {
+		t.Fatalf("corner distance to circle = %v, want about 19.3", got)
+	}
+	if got := fw.cursorDistanceToContent(fyne.NewPos(150, 250), pos, size); got != 0 {
+		t.Fatalf("center should be inside the circle, got %v", got)
+	}
+}
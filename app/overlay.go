@@ -0,0 +1,434 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+)
+
+// 图片上方的文字层设置，存成一个 JSON
+const overlaySettingsKey = "overlay.settings"
+
+const (
+	overlayKindNone      = "none"
+	overlayKindCaption   = "caption"
+	overlayKindQuote     = "quote"
+	overlayKindClock     = "clock"
+	overlayKindCountdown = "countdown"
+
+	overlayCornerTopLeft     = "top_left"
+	overlayCornerTopRight    = "top_right"
+	overlayCornerBottomLeft  = "bottom_left"
+	overlayCornerBottomRight = "bottom_right"
+
+	defaultOverlayFontSize = 16
+	minOverlayFontSize     = 8
+	maxOverlayFontSize     = 96
+	// 文字离窗口边缘的距离
+	overlayMargin = 8
+)
+
+var overlayKinds = []struct {
+	name  string
+	label string
+}{
+	{overlayKindNone, "不显示"},
+	{overlayKindCaption, "自定义文字"},
+	{overlayKindQuote, "每日一句"},
+	{overlayKindClock, "时钟"},
+	{overlayKindCountdown, "倒计时"},
+}
+
+var overlayCorners = []struct {
+	name  string
+	label string
+}{
+	{overlayCornerTopLeft, "左上"},
+	{overlayCornerTopRight, "右上"},
+	{overlayCornerBottomLeft, "左下"},
+	{overlayCornerBottomRight, "右下"},
+}
+
+var overlayColors = []struct {
+	label string
+	color color.NRGBA
+}{
+	{"白色", color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	{"黑色", color.NRGBA{A: 255}},
+	{"黄色", color.NRGBA{R: 255, G: 214, B: 10, A: 255}},
+	{"红色", color.NRGBA{R: 230, G: 60, B: 50, A: 255}},
+	{"青色", color.NRGBA{R: 80, G: 220, B: 230, A: 255}},
+}
+
+type overlaySettings struct {
+	Kind string `json:"kind"`
+	// Text 是自定义文字
+	Text string `json:"text,omitempty"`
+	// QuoteFile 每行一句，每天按日期轮换
+	QuoteFile string `json:"quote_file,omitempty"`
+	// Target 是倒计时的目标时间，支持 2006-01-02 15:04、2006-01-02 和每天的 15:04
+	Target   string  `json:"target,omitempty"`
+	FontSize float32 `json:"font_size,omitempty"`
+	Color    string  `json:"color,omitempty"`
+	Outline  bool    `json:"outline"`
+	Corner   string  `json:"corner,omitempty"`
+}
+
+func defaultOverlaySettings() overlaySettings {
+	return overlaySettings{
+		Kind:     overlayKindNone,
+		FontSize: defaultOverlayFontSize,
+		Color:    formatTintColor(overlayColors[0].color),
+		Outline:  true,
+		Corner:   overlayCornerBottomRight,
+	}
+}
+
+func normalizeOverlaySettings(s overlaySettings) overlaySettings {
+	def := defaultOverlaySettings()
+	if overlayKindLabel(s.Kind) == "" {
+		s.Kind = def.Kind
+	}
+	if s.FontSize <= 0 {
+		s.FontSize = def.FontSize
+	}
+	s.FontSize = min(max(s.FontSize, minOverlayFontSize), maxOverlayFontSize)
+	if _, ok := parseTintColor(s.Color); !ok {
+		s.Color = def.Color
+	}
+	if overlayCornerLabel(s.Corner) == "" {
+		s.Corner = def.Corner
+	}
+	s.Text = strings.TrimSpace(s.Text)
+	s.QuoteFile = strings.TrimSpace(s.QuoteFile)
+	s.Target = strings.TrimSpace(s.Target)
+	return s
+}
+
+func overlayKindLabel(name string) string {
+	for _, item := range overlayKinds {
+		if item.name == name {
+			return item.label
+		}
+	}
+	return ""
+}
+
+func overlayKindFromLabel(label string) string {
+	for _, item := range overlayKinds {
+		if item.label == label {
+			return item.name
+		}
+	}
+	return overlayKindNone
+}
+
+func overlayCornerLabel(name string) string {
+	for _, item := range overlayCorners {
+		if item.name == name {
+			return item.label
+		}
+	}
+	return ""
+}
+
+func overlayCornerFromLabel(label string) string {
+	for _, item := range overlayCorners {
+		if item.label == label {
+			return item.name
+		}
+	}
+	return overlayCornerBottomRight
+}
+
+// overlayText 返回 now 时刻文字层要显示的内容。
+func overlayText(s overlaySettings, now time.Time, quotes []string) string {
+	switch s.Kind {
+	case overlayKindCaption:
+		return s.Text
+	case overlayKindQuote:
+		return dailyQuote(quotes, now)
+	case overlayKindClock:
+		return now.Format("15:04")
+	case overlayKindCountdown:
+		target, ok := parseCountdownTarget(s.Target, now)
+		if !ok {
+			return ""
+		}
+		return formatCountdown(target.Sub(now))
+	}
+	return ""
+}
+
+// parseCountdownTarget 解析倒计时目标。只写时刻时指今天的这个时刻，已经过了就是明天的。
+func parseCountdownTarget(text string, now time.Time) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		t, err := time.ParseInLocation(layout, text, now.Location())
+		if err != nil {
+			continue
+		}
+		target := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+		if !target.After(now) {
+			target = target.AddDate(0, 0, 1)
+		}
+		return target, true
+	}
+	return time.Time{}, false
+}
+
+func formatCountdown(d time.Duration) string {
+	if d <= 0 {
+		return "时间到"
+	}
+	// 不足一秒按一秒显示，避免还没到就显示 00:00:00
+	secs := int64((d + time.Second - 1) / time.Second)
+	days := secs / 86400
+	secs %= 86400
+	clock := fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs%3600/60, secs%60)
+	if days > 0 {
+		return fmt.Sprintf("%d 天 %s", days, clock)
+	}
+	return clock
+}
+
+// dailyQuote 按日期轮换，同一天总是同一句。
+func dailyQuote(quotes []string, now time.Time) string {
+	if len(quotes) == 0 {
+		return ""
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+	return quotes[int(day%int64(len(quotes)))]
+}
+
+func loadQuotes(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("load quotes failed: %v", err)
+		return nil
+	}
+	var quotes []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			quotes = append(quotes, line)
+		}
+	}
+	return quotes
+}
+
+// textOverlay 是图片上方的文字层，描边用四份错开一像素的文字画在正文后面。
+type textOverlay struct {
+	mu       sync.Mutex
+	settings overlaySettings
+	quotes   []string
+	now      func() time.Time
+	stop     chan struct{}
+
+	texts  []*canvas.Text
+	object *fyne.Container
+}
+
+func newTextOverlay() *textOverlay {
+	o := &textOverlay{settings: defaultOverlaySettings(), now: time.Now}
+	objects := make([]fyne.CanvasObject, 0, 5)
+	for i := 0; i < 5; i++ {
+		text := canvas.NewText("", color.White)
+		o.texts = append(o.texts, text)
+		objects = append(objects, text)
+	}
+	o.object = container.New(&overlayLayout{overlay: o}, objects...)
+	o.object.Hide()
+	return o
+}
+
+var overlayOutlineOffsets = []fyne.Position{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: -1}, {X: 0, Y: 1}}
+
+func (o *textOverlay) main() *canvas.Text {
+	return o.texts[len(o.texts)-1]
+}
+
+// apply 换成新设置并刷新显示，时钟和倒计时每秒更新。
+func (o *textOverlay) apply(s overlaySettings) {
+	s = normalizeOverlaySettings(s)
+	var quotes []string
+	if s.Kind == overlayKindQuote {
+		quotes = loadQuotes(s.QuoteFile)
+	}
+
+	o.mu.Lock()
+	o.settings = s
+	o.quotes = quotes
+	o.mu.Unlock()
+
+	o.refresh()
+	o.stopTicker()
+	switch s.Kind {
+	case overlayKindClock, overlayKindCountdown:
+		o.startTicker(time.Second)
+	case overlayKindQuote:
+		// 跨天时换一句
+		o.startTicker(time.Minute)
+	}
+}
+
+func (o *textOverlay) refresh() {
+	o.mu.Lock()
+	s := o.settings
+	text := overlayText(s, o.now(), o.quotes)
+	o.mu.Unlock()
+
+	fill, _ := parseTintColor(s.Color)
+	fill.A = 255
+	outline := color.NRGBA{A: 200}
+	// 深色文字配浅色描边
+	if luminance(fill) < 0.5 {
+		outline = color.NRGBA{R: 255, G: 255, B: 255, A: 200}
+	}
+	for i, t := range o.texts {
+		t.Text = text
+		t.TextSize = s.FontSize
+		t.TextStyle = fyne.TextStyle{Bold: true}
+		t.Color = fill
+		if i < len(o.texts)-1 {
+			t.Color = outline
+			t.Hidden = !s.Outline
+		}
+	}
+	if text == "" || s.Kind == overlayKindNone {
+		o.object.Hide()
+	} else {
+		o.object.Show()
+	}
+	o.object.Refresh()
+}
+
+func luminance(c color.NRGBA) float64 {
+	return (0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)) / 255
+}
+
+func (o *textOverlay) startTicker(interval time.Duration) {
+	stop := make(chan struct{})
+	o.mu.Lock()
+	o.stop = stop
+	o.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(o.refresh)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (o *textOverlay) stopTicker() {
+	o.mu.Lock()
+	stop := o.stop
+	o.stop = nil
+	o.mu.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+}
+
+type overlayLayout struct {
+	overlay *textOverlay
+}
+
+func (l *overlayLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	o := l.overlay
+	o.mu.Lock()
+	corner := o.settings.Corner
+	o.mu.Unlock()
+
+	textSize := o.main().MinSize()
+	pos := fyne.NewPos(overlayMargin, overlayMargin)
+	if corner == overlayCornerTopRight || corner == overlayCornerBottomRight {
+		pos.X = size.Width - textSize.Width - overlayMargin
+	}
+	if corner == overlayCornerBottomLeft || corner == overlayCornerBottomRight {
+		pos.Y = size.Height - textSize.Height - overlayMargin
+	}
+	for i, t := range o.texts {
+		p := pos
+		if i < len(overlayOutlineOffsets) {
+			p = pos.Add(overlayOutlineOffsets[i])
+		}
+		t.Move(p)
+		t.Resize(textSize)
+	}
+}
+
+func (l *overlayLayout) MinSize([]fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 0)
+}
+
+// restoreOverlay 读取文字层设置，默认不显示。
+func (f *FloatingWindow) restoreOverlay() {
+	if f == nil || f.overlay == nil {
+		return
+	}
+	f.overlay.apply(f.OverlaySettings())
+}
+
+func (f *FloatingWindow) OverlaySettings() overlaySettings {
+	s := defaultOverlaySettings()
+	if f == nil || f.App == nil {
+		return s
+	}
+	raw := strings.TrimSpace(f.App.Preferences().String(overlaySettingsKey))
+	if raw == "" {
+		return s
+	}
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		log.Printf("load overlay settings failed: %v", err)
+		return defaultOverlaySettings()
+	}
+	return normalizeOverlaySettings(s)
+}
+
+func (f *FloatingWindow) SetOverlaySettings(s overlaySettings) {
+	if f == nil {
+		return
+	}
+	s = normalizeOverlaySettings(s)
+	if f.App != nil {
+		data, err := json.Marshal(s)
+		if err != nil {
+			log.Printf("save overlay settings failed: %v", err)
+		} else {
+			f.App.Preferences().SetString(overlaySettingsKey, string(data))
+		}
+	}
+	if f.overlay != nil {
+		f.overlay.apply(s)
+	}
+}
+
+func (f *FloatingWindow) stopOverlay() {
+	if f != nil && f.overlay != nil {
+		f.overlay.stopTicker()
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestOverlayText(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 14, 9, 30, 15, 0, time.Local)
+	quotes := []string{"a", "b", "c"}
+	tests := []struct {
+		name string
+		s    overlaySettings
+		want string
+	}{
+		{"none", overlaySettings{Kind: overlayKindNone, Text: "x"}, ""},
+		{"caption", overlaySettings{Kind: overlayKindCaption, Text: "hello"}, "hello"},
+		{"clock", overlaySettings{Kind: overlayKindClock}, "09:30"},
+		{"countdown same day", overlaySettings{Kind: overlayKindCountdown, Target: "10:00"}, "00:29:45"},
+		{"countdown next day", overlaySettings{Kind: overlayKindCountdown, Target: "09:00"}, "23:29:45"},
+		{"countdown days", overlaySettings{Kind: overlayKindCountdown, Target: "2026-03-16 09:30"}, "1 天 23:59:45"},
+		{"countdown passed", overlaySettings{Kind: overlayKindCountdown, Target: "2026-03-01"}, "时间到"},
+		{"countdown invalid", overlaySettings{Kind: overlayKindCountdown, Target: "soon"}, ""},
+		{"quote", overlaySettings{Kind: overlayKindQuote}, dailyQuote(quotes, now)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := overlayText(tt.s, now, quotes); got != tt.want {
+				t.Fatalf("overlayText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyQuote_ChangesByDay(t *testing.T) {
+	t.Parallel()
+
+	quotes := []string{"a", "b", "c"}
+	morning := time.Date(2026, 3, 14, 0, 1, 0, 0, time.Local)
+	night := time.Date(2026, 3, 14, 23, 59, 0, 0, time.Local)
+	if dailyQuote(quotes, morning) != dailyQuote(quotes, night) {
+		t.Fatalf("the quote should stay the same within a day")
+	}
+	if dailyQuote(quotes, morning) == dailyQuote(quotes, morning.AddDate(0, 0, 1)) {
+		t.Fatalf("the quote should change on the next day")
+	}
+	if dailyQuote(nil, morning) != "" {
+		t.Fatalf("no quotes should show nothing")
+	}
+}
+
+func TestLoadQuotes_SkipsBlankLines(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "quotes.txt")
+	if err := os.WriteFile(path, []byte("first\r\n\r\n  second  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := loadQuotes(path)
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("loadQuotes = %q", got)
+	}
+}
+
+func TestOverlaySettings_PersistAndRestore(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+
+	fw := &FloatingWindow{App: a, overlay: newTextOverlay()}
+	fw.restoreOverlay()
+	if got := fw.OverlaySettings(); got != defaultOverlaySettings() {
+		t.Fatalf("default overlay = %+v", got)
+	}
+	if fw.overlay.object.Visible() {
+		t.Fatalf("overlay should be hidden by default")
+	}
+
+	want := overlaySettings{
+		Kind:     overlayKindCaption,
+		Text:     "hi",
+		FontSize: 200,
+		Color:    "#ffd60a",
+		Corner:   overlayCornerTopLeft,
+	}
+	fw.SetOverlaySettings(want)
+	t.Cleanup(fw.stopOverlay)
+	want.FontSize = maxOverlayFontSize
+
+	restored := &FloatingWindow{App: a, overlay: newTextOverlay()}
+	restored.restoreOverlay()
+	if got := restored.OverlaySettings(); got != want {
+		t.Fatalf("restored overlay = %+v, want %+v", got, want)
+	}
+	if !restored.overlay.object.Visible() || restored.overlay.main().Text != "hi" {
+		t.Fatalf("caption should be visible after restore")
+	}
+	for _, text := range restored.overlay.texts[:4] {
+		if text.Visible() {
+			t.Fatalf("outline should be hidden when disabled")
+		}
+	}
+}
+
+func TestOverlayLayout_Corners(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+
+	o := newTextOverlay()
+	size := fyne.NewSize(300, 200)
+	for _, corner := range []string{overlayCornerTopLeft, overlayCornerBottomRight} {
+		o.apply(overlaySettings{Kind: overlayKindCaption, Text: "corner", Corner: corner})
+		o.object.Resize(size)
+		o.object.Refresh()
+		pos := o.main().Position()
+		textSize := o.main().MinSize()
+		switch corner {
+		case overlayCornerTopLeft:
+			if pos != fyne.NewPos(overlayMargin, overlayMargin) {
+				t.Fatalf("top left position = %v", pos)
+			}
+		case overlayCornerBottomRight:
+			want := fyne.NewPos(size.Width-textSize.Width-overlayMargin, size.Height-textSize.Height-overlayMargin)
+			if pos != want {
+				t.Fatalf("bottom right position = %v, want %v", pos, want)
+			}
+		}
+	}
+}
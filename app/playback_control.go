@@ -0,0 +1,108 @@
+package app
+
+import (
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+)
+
+var playbackSpeedPresets = []float64{0.25, 0.5, 0.75, 1, 1.5, 2, 3, 4}
+
+func playbackSpeedLabel(speed float64) string {
+	return strconv.FormatFloat(speed, 'f', -1, 64) + "×"
+}
+
+// nextPlaybackSpeed 返回比 current 快（或慢）一档的预设速度，已是最快（最慢）时不变。
+func nextPlaybackSpeed(current float64, faster bool) float64 {
+	if faster {
+		for _, s := range playbackSpeedPresets {
+			if s > current+1e-9 {
+				return s
+			}
+		}
+		return playbackSpeedPresets[len(playbackSpeedPresets)-1]
+	}
+	for i := len(playbackSpeedPresets) - 1; i >= 0; i-- {
+		if s := playbackSpeedPresets[i]; s < current-1e-9 {
+			return s
+		}
+	}
+	return playbackSpeedPresets[0]
+}
+
+func samePlaybackSpeed(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func (f *FloatingWindow) PlaybackSpeed() float64 {
+	if f == nil || f.Player == nil {
+		return 1
+	}
+	return f.Player.Speed()
+}
+
+func (f *FloatingWindow) SetPlaybackSpeed(speed float64) {
+	if f == nil || f.Player == nil {
+		return
+	}
+	f.Player.SetSpeed(speed)
+	f.notifyPlaybackChanged()
+}
+
+func (f *FloatingWindow) IsPlaybackPaused() bool {
+	return f != nil && f.Player != nil && f.Player.UserPaused()
+}
+
+// TogglePlaybackPaused 切换用户暂停，返回切换后是否暂停。
+func (f *FloatingWindow) TogglePlaybackPaused() bool {
+	if f == nil || f.Player == nil {
+		return false
+	}
+	paused := f.Player.TogglePause()
+	f.notifyPlaybackChanged()
+	return paused
+}
+
+func (f *FloatingWindow) StepPlayback(forward bool) {
+	if f == nil || f.Player == nil {
+		return
+	}
+	if forward {
+		f.Player.StepForward()
+	} else {
+		f.Player.StepBackward()
+	}
+	f.notifyPlaybackChanged()
+}
+
+func (f *FloatingWindow) notifyPlaybackChanged() {
+	if f.onPlaybackChanged != nil {
+		f.onPlaybackChanged()
+	}
+}
+
+// handlePlaybackKey 处理编辑模式下的播放控制按键，返回是否处理了这个按键。
+func (f *FloatingWindow) handlePlaybackKey(ev *fyne.KeyEvent) bool {
+	if f == nil || f.Player == nil || ev == nil || !f.IsEditMode() {
+		return false
+	}
+
+	switch ev.Name {
+	case fyne.KeySpace:
+		f.TogglePlaybackPaused()
+	case fyne.KeyRight, fyne.KeyPeriod:
+		f.StepPlayback(true)
+	case fyne.KeyLeft, fyne.KeyComma:
+		f.StepPlayback(false)
+	case fyne.KeyUp, fyne.KeyEqual, fyne.KeyPlus:
+		f.SetPlaybackSpeed(nextPlaybackSpeed(f.PlaybackSpeed(), true))
+	case fyne.KeyDown, fyne.KeyMinus:
+		f.SetPlaybackSpeed(nextPlaybackSpeed(f.PlaybackSpeed(), false))
+	case fyne.Key0:
+		f.SetPlaybackSpeed(1)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestNextPlaybackSpeed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		current float64
+		faster  bool
+		want    float64
+	}{
+		{current: 1, faster: true, want: 1.5},
+		{current: 1, faster: false, want: 0.75},
+		{current: 4, faster: true, want: 4},
+		{current: 0.25, faster: false, want: 0.25},
+		{current: 1.2, faster: true, want: 1.5},
+		{current: 1.2, faster: false, want: 1},
+	}
+
+	for _, tc := range tests {
+		if got := nextPlaybackSpeed(tc.current, tc.faster); got != tc.want {
+			t.Fatalf("nextPlaybackSpeed(%v, %v) = %v, want %v", tc.current, tc.faster, got, tc.want)
+		}
+	}
+}
+
+func TestPlaybackSpeedLabel(t *testing.T) {
+	t.Parallel()
+
+	if got := playbackSpeedLabel(0.25); got != "0.25×" {
+		t.Fatalf("playbackSpeedLabel(0.25) = %q", got)
+	}
+	if got := playbackSpeedLabel(2); got != "2×" {
+		t.Fatalf("playbackSpeedLabel(2) = %q", got)
+	}
+}
+
+func TestHandlePlaybackKey(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	changed := 0
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.onPlaybackChanged = func() { changed++ }
+
+	if fw.handlePlaybackKey(&fyne.KeyEvent{Name: fyne.KeySpace}) {
+		t.Fatalf("keys should be ignored outside edit mode")
+	}
+
+	fw.editMode.Store(true)
+	if !fw.handlePlaybackKey(&fyne.KeyEvent{Name: fyne.KeySpace}) || !fw.IsPlaybackPaused() {
+		t.Fatalf("space should pause playback")
+	}
+	fw.handlePlaybackKey(&fyne.KeyEvent{Name: fyne.KeyUp})
+	if got := fw.PlaybackSpeed(); got != 1.5 {
+		t.Fatalf("speed after up = %v, want 1.5", got)
+	}
+	fw.handlePlaybackKey(&fyne.KeyEvent{Name: fyne.Key0})
+	if got := fw.PlaybackSpeed(); got != 1 {
+		t.Fatalf("speed after 0 = %v, want 1", got)
+	}
+	if fw.handlePlaybackKey(&fyne.KeyEvent{Name: fyne.KeyA}) {
+		t.Fatalf("unrelated keys should not be handled")
+	}
+	if changed != 3 {
+		t.Fatalf("onPlaybackChanged called %d times, want 3", changed)
+	}
+}
@@ -0,0 +1,189 @@
+package player
+
+import (
+	"context"
+	"image"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const minFrameDelay = time.Second / 60
+
+// Animation 是解码后的动图，GIF、WebP 等格式都转换成它，再交给同一个帧循环播放。
+// Frames 中每一帧都应是合成好的整张画面，尺寸为 Width x Height。
+type Animation struct {
+	Width  int
+	Height int
+	Frames []image.Image
+	Delays []time.Duration
+	// 文件里写的总播放次数，0 表示无限循环
+	LoopCount int
+
+	// 不为 nil 时帧按需合成，Frames 为空
+	stream *gifStream
+}
+
+func (a *Animation) frameCount() int {
+	if a == nil {
+		return 0
+	}
+	if a.stream != nil {
+		return a.stream.len()
+	}
+	return len(a.Frames)
+}
+
+func (a *Animation) frameAt(i int) image.Image {
+	if a.stream != nil {
+		return a.stream.frame(i)
+	}
+	if i < 0 || i >= len(a.Frames) {
+		return nil
+	}
+	return a.Frames[i]
+}
+
+func (a *Animation) delayAt(i int) time.Duration {
+	if a == nil || i < 0 || i >= len(a.Delays) {
+		return minFrameDelay
+	}
+	return normalizeFrameDelay(a.Delays[i])
+}
+
+func normalizeFrameDelay(delay time.Duration) time.Duration {
+	if delay < minFrameDelay {
+		return minFrameDelay
+	}
+	return delay
+}
+
+func playAnimation(p *Player, anim *Animation, playbackID uint64) {
+	if anim.frameCount() == 0 {
+		return
+	}
+	if !p.isPlaybackActive(playbackID) {
+		return
+	}
+
+	// 帧循环要等窗口尺寸更新、过渡准备好之后再开始
+	ready := make(chan struct{})
+	fyne.Do(func() {
+		defer close(ready)
+		if !p.isPlaybackActive(playbackID) {
+			return
+		}
+		p.updateBaseSizeWithTransition(playbackID, anim.Width, anim.Height)
+	})
+
+	loop := p.LoopSettings()
+	p.setAnimation(playbackID, anim)
+	p.goPlayback(playbackID, func(ctx context.Context) {
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return
+		}
+
+		sleep := func(d time.Duration) bool {
+			return sleepContext(ctx, d)
+		}
+		clock := newFrameClock(time.Now, sleep)
+		cursor := newFrameCursor(anim.frameCount(), anim.LoopCount, loop)
+		start, replay := p.replayStart(playbackID)
+		if start > 0 {
+			// seek 之后从下一帧播起，重播要从原来那一帧开始
+			cursor.seek(start - 1)
+		}
+		first := true
+		for {
+			if !p.isPlaybackActive(playbackID) {
+				return
+			}
+			// 重播时即使暂停着，也要先按新的设置画出原来那一帧
+			if p.RenderPaused() && !(first && replay) {
+				pausedAt := time.Now()
+				if !p.waitRenderResumed(playbackID) {
+					return
+				}
+				clock.shift(time.Since(pausedAt))
+				// 暂停时单帧步进过，就从步进到的那一帧往后播
+				if index, ok := p.takeSteppedFrame(playbackID); ok {
+					cursor.seek(index)
+				}
+			}
+
+			i, last, ok := cursor.next()
+			if !ok {
+				return
+			}
+			delay := scaleFrameDelay(anim.delayAt(i), p.Speed())
+			// 最后一帧要停留在画面上，不能跳过
+			if !last && clock.skip(delay) {
+				continue
+			}
+
+			p.showingFrame(playbackID, i)
+			frame := p.renderFrame(playbackID, i, anim.frameAt(i), anim.stream == nil)
+			if first && p.runTransition(ctx, playbackID, frame) {
+				// 过渡期间第一帧一直在显示，从过渡结束时重新计时
+				clock = newFrameClock(time.Now, sleep)
+			} else {
+				fyne.Do(func() {
+					if !p.isPlaybackActive(playbackID) {
+						return
+					}
+					p.Canvas.Image = frame
+					p.Canvas.Refresh()
+				})
+			}
+			first = false
+			if last || !clock.wait(delay) {
+				return
+			}
+		}
+	})
+}
+
+func showStillImage(p *Player, img image.Image, playbackID uint64) {
+	if img == nil || !p.isPlaybackActive(playbackID) {
+		return
+	}
+
+	p.setAnimation(playbackID, nil)
+	kbSettings := p.KenBurns()
+	var kbSrc image.Image
+	var kbFilters FilterSettings
+	if kbSettings.Enabled {
+		kbSrc = transformImage(cropImage(img, p.Crop()), p.Transform())
+		kbFilters = p.Filters()
+	}
+	fyne.Do(func() {
+		if !p.isPlaybackActive(playbackID) {
+			return
+		}
+		b := img.Bounds()
+		p.updateBaseSizeWithTransition(playbackID, b.Dx(), b.Dy())
+		if kbSrc != nil {
+			// 平移缩放的画面由 runKenBurns 持续更新，不参与缩放后的重新渲染
+			p.clearCurrentFrame()
+			// 窗口尺寸更新后再准备，矢量图按新图的渲染尺寸栅格化
+			renderSize := p.currentRenderSize()
+			p.goPlayback(playbackID, func(ctx context.Context) {
+				kb := newKenBurns(kbSrc, renderSize, kbSettings, kbFilters, p.kenBurnsRand)
+				p.runTransition(ctx, playbackID, kb.frame(0, p.currentRenderSize(), p.currentMask()))
+				p.runKenBurns(ctx, playbackID, kb)
+			})
+			return
+		}
+		frame := p.renderFrame(playbackID, 0, img, true)
+		if p.hasPendingTransition(playbackID) {
+			p.goPlayback(playbackID, func(ctx context.Context) {
+				p.runTransition(ctx, playbackID, frame)
+			})
+			return
+		}
+		p.Canvas.Image = frame
+		p.Canvas.Refresh()
+	})
+}
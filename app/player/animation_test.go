@@ -0,0 +1,26 @@
+package player
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnimationDelayAt(t *testing.T) {
+	t.Parallel()
+
+	anim := &Animation{Delays: []time.Duration{0, 40 * time.Millisecond}}
+	if got := anim.delayAt(0); got != minFrameDelay {
+		t.Fatalf("delayAt(0) = %v, want %v", got, minFrameDelay)
+	}
+	if got := anim.delayAt(1); got != 40*time.Millisecond {
+		t.Fatalf("delayAt(1) = %v, want 40ms", got)
+	}
+	if got := anim.delayAt(5); got != minFrameDelay {
+		t.Fatalf("delayAt(out-of-range) = %v, want %v", got, minFrameDelay)
+	}
+
+	var nilAnim *Animation
+	if got := nilAnim.delayAt(0); got != minFrameDelay {
+		t.Fatalf("nil delayAt = %v, want %v", got, minFrameDelay)
+	}
+}
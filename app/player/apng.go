@@ -0,0 +1,289 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"time"
+)
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// fcTL 中的 dispose_op / blend_op
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+	apngBlendOver   = 1
+)
+
+var errInvalidAPNG = errors.New("png: invalid apng")
+
+type pngDecoder struct{}
+
+func (pngDecoder) Name() string { return "png" }
+
+func (pngDecoder) Extensions() []string { return []string{"png"} }
+
+func (pngDecoder) Sniff(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(pngSignature))
+}
+
+func (pngDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	return png.DecodeConfig(r)
+}
+
+func (pngDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	return png.Decode(r)
+}
+
+// DecodeAnimation 只有带 acTL 的 APNG 才返回动画，普通 PNG 返回 nil。
+func (pngDecoder) DecodeAnimation(r io.Reader) (*Animation, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !isAPNG(data) {
+		return nil, nil
+	}
+	return decodeAPNG(data)
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if len(data) < len(pngSignature) || string(data[:len(pngSignature)]) != pngSignature {
+		return nil, errInvalidAPNG
+	}
+	data = data[len(pngSignature):]
+
+	var chunks []pngChunk
+	for len(data) >= 12 {
+		size := int(binary.BigEndian.Uint32(data[0:4]))
+		if size < 0 || 12+size > len(data) {
+			return nil, errInvalidAPNG
+		}
+		typ := string(data[4:8])
+		chunks = append(chunks, pngChunk{typ: typ, data: data[8 : 8+size]})
+		data = data[12+size:]
+		if typ == "IEND" {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// isAPNG 判断 acTL 是否出现在第一个 IDAT 之前，只扫描 chunk 头，不解压数据。
+func isAPNG(data []byte) bool {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return false
+	}
+	for _, c := range chunks {
+		switch c.typ {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+	}
+	return false
+}
+
+type apngFrameControl struct {
+	width, height int
+	x, y          int
+	delay         time.Duration
+	disposeOp     byte
+	blendOp       byte
+}
+
+func parseAPNGFrameControl(data []byte) (apngFrameControl, error) {
+	if len(data) < 26 {
+		return apngFrameControl{}, errInvalidAPNG
+	}
+	fc := apngFrameControl{
+		width:     int(binary.BigEndian.Uint32(data[4:8])),
+		height:    int(binary.BigEndian.Uint32(data[8:12])),
+		x:         int(binary.BigEndian.Uint32(data[12:16])),
+		y:         int(binary.BigEndian.Uint32(data[16:20])),
+		disposeOp: data[24],
+		blendOp:   data[25],
+	}
+	num := binary.BigEndian.Uint16(data[20:22])
+	den := binary.BigEndian.Uint16(data[22:24])
+	if den == 0 {
+		den = 100
+	}
+	fc.delay = time.Duration(num) * time.Second / time.Duration(den)
+	if fc.width <= 0 || fc.height <= 0 {
+		return apngFrameControl{}, errInvalidAPNG
+	}
+	return fc, nil
+}
+
+type apngFrame struct {
+	control apngFrameControl
+	data    [][]byte
+}
+
+func decodeAPNG(data []byte) (*Animation, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 || chunks[0].typ != "IHDR" || len(chunks[0].data) != 13 {
+		return nil, errInvalidAPNG
+	}
+	ihdr := chunks[0].data
+	width := int(binary.BigEndian.Uint32(ihdr[0:4]))
+	height := int(binary.BigEndian.Uint32(ihdr[4:8]))
+	if width <= 0 || height <= 0 {
+		return nil, errInvalidAPNG
+	}
+
+	var (
+		loopCount int
+		shared    []pngChunk
+		frames    []*apngFrame
+		current   *apngFrame
+		seenIDAT  bool
+	)
+	for _, c := range chunks[1:] {
+		switch c.typ {
+		case "acTL":
+			if len(c.data) < 8 {
+				return nil, errInvalidAPNG
+			}
+			loopCount = int(binary.BigEndian.Uint32(c.data[4:8]))
+		case "fcTL":
+			fc, err := parseAPNGFrameControl(c.data)
+			if err != nil {
+				return nil, err
+			}
+			current = &apngFrame{control: fc}
+			frames = append(frames, current)
+		case "IDAT":
+			seenIDAT = true
+			// fcTL 在 IDAT 之前时，默认图就是第一帧；否则默认图不参与动画
+			if current != nil {
+				current.data = append(current.data, c.data)
+			}
+		case "fdAT":
+			if current == nil || len(c.data) < 4 {
+				return nil, errInvalidAPNG
+			}
+			current.data = append(current.data, c.data[4:])
+		case "IEND":
+		default:
+			// PLTE、tRNS、gAMA 等出现在图像数据前的 chunk 每一帧都需要
+			if !seenIDAT {
+				shared = append(shared, c)
+			}
+		}
+	}
+	if len(frames) == 0 {
+		return nil, errInvalidAPNG
+	}
+
+	bounds := image.Rect(0, 0, width, height)
+	canvas := image.NewRGBA(bounds)
+	transparent := image.NewUniform(color.Transparent)
+
+	anim := &Animation{
+		Width:     width,
+		Height:    height,
+		Frames:    make([]image.Image, 0, len(frames)),
+		Delays:    make([]time.Duration, 0, len(frames)),
+		LoopCount: loopCount,
+	}
+
+	for i, f := range frames {
+		if len(f.data) == 0 {
+			continue
+		}
+		fc := f.control
+		img, err := decodeAPNGFrame(ihdr, shared, fc, f.data)
+		if err != nil {
+			return nil, err
+		}
+
+		rect := image.Rect(fc.x, fc.y, fc.x+fc.width, fc.y+fc.height).Intersect(bounds)
+		disposeOp := fc.disposeOp
+		if i == 0 && disposeOp == apngDisposePrevious {
+			disposeOp = apngDisposeBackground
+		}
+
+		var restore *image.RGBA
+		if disposeOp == apngDisposePrevious {
+			restore = cloneRGBA(canvas, bounds)
+		}
+
+		op := draw.Over
+		if fc.blendOp == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, rect, img, img.Bounds().Min, op)
+
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas, bounds))
+		anim.Delays = append(anim.Delays, normalizeFrameDelay(fc.delay))
+
+		switch disposeOp {
+		case apngDisposeBackground:
+			draw.Draw(canvas, rect, transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			draw.Draw(canvas, bounds, restore, bounds.Min, draw.Src)
+		}
+	}
+	if len(anim.Frames) == 0 {
+		return nil, errInvalidAPNG
+	}
+	return anim, nil
+}
+
+// decodeAPNGFrame 把一帧的数据拼成独立的 PNG，复用标准库解码。
+func decodeAPNGFrame(ihdr []byte, shared []pngChunk, fc apngFrameControl, data [][]byte) (image.Image, error) {
+	frameIHDR := make([]byte, len(ihdr))
+	copy(frameIHDR, ihdr)
+	binary.BigEndian.PutUint32(frameIHDR[0:4], uint32(fc.width))
+	binary.BigEndian.PutUint32(frameIHDR[4:8], uint32(fc.height))
+
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+	writePNGChunk(&buf, "IHDR", frameIHDR)
+	for _, c := range shared {
+		writePNGChunk(&buf, c.typ, c.data)
+	}
+	for _, d := range data {
+		writePNGChunk(&buf, "IDAT", d)
+	}
+	writePNGChunk(&buf, "IEND", nil)
+
+	return png.Decode(&buf)
+}
+
+func writePNGChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(data)))
+	copy(header[4:8], typ)
+	buf.Write(header[:])
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:8])
+	crc.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
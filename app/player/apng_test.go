@@ -0,0 +1,209 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+type testAPNGFrame struct {
+	rect      image.Rectangle
+	color     color.NRGBA
+	delayNum  uint16
+	delayDen  uint16
+	disposeOp byte
+	blendOp   byte
+}
+
+func encodeTestPNG(t *testing.T, w, h int, c color.NRGBA) []pngChunk {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		t.Fatalf("read png chunks: %v", err)
+	}
+	return chunks
+}
+
+// buildTestAPNG 第一帧同时作为默认图（fcTL 在 IDAT 之前）。
+// 所有帧都要是不透明色，保证编码出的颜色类型和 IHDR 一致。
+func buildTestAPNG(t *testing.T, width, height int, numPlays uint32, frames []testAPNGFrame) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.WriteString(pngSignature)
+
+	seq := uint32(0)
+	for i, f := range frames {
+		chunks := encodeTestPNG(t, f.rect.Dx(), f.rect.Dy(), f.color)
+		if i == 0 {
+			ihdr := make([]byte, len(chunks[0].data))
+			copy(ihdr, chunks[0].data)
+			binary.BigEndian.PutUint32(ihdr[0:4], uint32(width))
+			binary.BigEndian.PutUint32(ihdr[4:8], uint32(height))
+			writePNGChunk(&buf, "IHDR", ihdr)
+
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:4], uint32(len(frames)))
+			binary.BigEndian.PutUint32(actl[4:8], numPlays)
+			writePNGChunk(&buf, "acTL", actl)
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], seq)
+		seq++
+		binary.BigEndian.PutUint32(fctl[4:8], uint32(f.rect.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:12], uint32(f.rect.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:16], uint32(f.rect.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:20], uint32(f.rect.Min.Y))
+		binary.BigEndian.PutUint16(fctl[20:22], f.delayNum)
+		binary.BigEndian.PutUint16(fctl[22:24], f.delayDen)
+		fctl[24] = f.disposeOp
+		fctl[25] = f.blendOp
+		writePNGChunk(&buf, "fcTL", fctl)
+
+		for _, c := range chunks {
+			if c.typ != "IDAT" {
+				continue
+			}
+			if i == 0 {
+				writePNGChunk(&buf, "IDAT", c.data)
+				continue
+			}
+			fdat := make([]byte, 4+len(c.data))
+			binary.BigEndian.PutUint32(fdat[0:4], seq)
+			seq++
+			copy(fdat[4:], c.data)
+			writePNGChunk(&buf, "fdAT", fdat)
+		}
+	}
+	writePNGChunk(&buf, "IEND", nil)
+	return buf.Bytes()
+}
+
+func TestIsAPNG(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestAPNG(t, 2, 2, 0, []testAPNGFrame{
+		{rect: image.Rect(0, 0, 2, 2), color: color.NRGBA{R: 255, A: 255}},
+	})
+	if !isAPNG(data) {
+		t.Fatalf("apng should be detected")
+	}
+
+	var plain bytes.Buffer
+	if err := png.Encode(&plain, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	if isAPNG(plain.Bytes()) {
+		t.Fatalf("plain png should not be detected as apng")
+	}
+	if isAPNG([]byte("nope")) {
+		t.Fatalf("garbage should not be detected as apng")
+	}
+}
+
+func TestDecodeAPNG_DisposeAndBlend(t *testing.T) {
+	t.Parallel()
+
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	green := color.NRGBA{G: 255, A: 255}
+
+	data := buildTestAPNG(t, 4, 2, 2, []testAPNGFrame{
+		{rect: image.Rect(0, 0, 4, 2), color: red, delayNum: 1, delayDen: 10},
+		{rect: image.Rect(2, 0, 4, 2), color: blue, delayNum: 5, disposeOp: apngDisposePrevious, blendOp: apngBlendOver},
+		{rect: image.Rect(0, 0, 2, 2), color: green, delayNum: 0, delayDen: 1, disposeOp: apngDisposeBackground},
+		{rect: image.Rect(3, 1, 4, 2), color: color.NRGBA{R: 255, G: 255, A: 255}, blendOp: apngBlendOver},
+	})
+
+	anim, err := decodeAPNG(data)
+	if err != nil {
+		t.Fatalf("decodeAPNG: %v", err)
+	}
+	if anim.Width != 4 || anim.Height != 2 || anim.LoopCount != 2 {
+		t.Fatalf("anim = %dx%d loop %d, want 4x2 loop 2", anim.Width, anim.Height, anim.LoopCount)
+	}
+	if len(anim.Frames) != 4 {
+		t.Fatalf("len(frames) = %d, want 4", len(anim.Frames))
+	}
+
+	assertRGBA(t, anim.Frames[0].At(3, 0), color.RGBA{255, 0, 0, 255})
+	assertRGBA(t, anim.Frames[1].At(3, 0), color.RGBA{0, 0, 255, 255})
+	assertRGBA(t, anim.Frames[1].At(0, 0), color.RGBA{255, 0, 0, 255})
+	// 第二帧 dispose previous：第三帧的右半边恢复成红色
+	assertRGBA(t, anim.Frames[2].At(3, 0), color.RGBA{255, 0, 0, 255})
+	assertRGBA(t, anim.Frames[2].At(0, 0), color.RGBA{0, 255, 0, 255})
+	// 第三帧 dispose background：左半边被清空
+	assertRGBA(t, anim.Frames[3].At(0, 0), color.RGBA{0, 0, 0, 0})
+	assertRGBA(t, anim.Frames[3].At(3, 0), color.RGBA{255, 0, 0, 255})
+	assertRGBA(t, anim.Frames[3].At(3, 1), color.RGBA{255, 255, 0, 255})
+
+	if got := anim.delayAt(0); got != 100*time.Millisecond {
+		t.Fatalf("delay[0] = %v, want 100ms", got)
+	}
+	if got := anim.delayAt(1); got != 50*time.Millisecond {
+		t.Fatalf("delay[1] (den=0 means 1/100s) = %v, want 50ms", got)
+	}
+	if got := anim.delayAt(2); got != minFrameDelay {
+		t.Fatalf("delay[2] = %v, want %v", got, minFrameDelay)
+	}
+}
+
+func TestDecodeAPNG_WithoutFrameControlFails(t *testing.T) {
+	t.Parallel()
+
+	var plain bytes.Buffer
+	if err := png.Encode(&plain, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	if _, err := decodeAPNG(plain.Bytes()); err == nil {
+		t.Fatalf("plain png should not decode as apng")
+	}
+}
+
+func TestPlay_PlainPNGStillWorks(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	p := NewPlayer(a, w)
+	imgPath := filepath.Join(t.TempDir(), "plain.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 5, 3))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	if err := os.WriteFile(imgPath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write png: %v", err)
+	}
+
+	p.Play(imgPath)
+	fyne.DoAndWait(func() {})
+
+	if p.Canvas.Image == nil {
+		t.Fatalf("canvas image should be set")
+	}
+	if p.baseSize.Width != 5 || p.baseSize.Height != 3 {
+		t.Fatalf("base size = (%v,%v), want (5,3)", p.baseSize.Width, p.baseSize.Height)
+	}
+}
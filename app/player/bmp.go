@@ -0,0 +1,40 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+
+	"golang.org/x/image/bmp"
+)
+
+type bmpDecoder struct{}
+
+func (bmpDecoder) Name() string { return "bmp" }
+
+func (bmpDecoder) Extensions() []string { return []string{"bmp"} }
+
+// Sniff 除了 "BM" 还检查 DIB 头长度，避免把以 BM 开头的文本文件当成位图。
+func (bmpDecoder) Sniff(header []byte) bool {
+	if len(header) < 18 || !bytes.HasPrefix(header, []byte("BM")) {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(header[14:18]) {
+	case 12, 40, 52, 56, 64, 108, 124:
+		return true
+	}
+	return false
+}
+
+func (bmpDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	return bmp.DecodeConfig(r)
+}
+
+func (bmpDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	return bmp.Decode(r)
+}
+
+func (bmpDecoder) DecodeAnimation(io.Reader) (*Animation, error) {
+	return nil, nil
+}
@@ -0,0 +1,141 @@
+package player
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	MinSpeed = 0.25
+	MaxSpeed = 4.0
+)
+
+// SetSpeed 设置动图播放速度倍数，范围 0.25~4，正在播放的动图从下一帧开始生效。
+func (p *Player) SetSpeed(factor float64) {
+	if factor <= 0 {
+		factor = 1
+	}
+	factor = min(max(factor, MinSpeed), MaxSpeed)
+	p.settingsMu.Lock()
+	p.speed = factor
+	p.settingsMu.Unlock()
+}
+
+func (p *Player) Speed() float64 {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	if p.speed <= 0 {
+		return 1
+	}
+	return p.speed
+}
+
+func scaleFrameDelay(delay time.Duration, speed float64) time.Duration {
+	if speed <= 0 || speed == 1 {
+		return delay
+	}
+	return time.Duration(float64(delay) / speed)
+}
+
+// Pause 由用户暂停动图，和拖拽、全透明等其它暂停原因互不影响。
+func (p *Player) Pause() {
+	p.setPauseReason(pauseReasonUser, true)
+}
+
+func (p *Player) Resume() {
+	p.setPauseReason(pauseReasonUser, false)
+}
+
+func (p *Player) UserPaused() bool {
+	return p.pauseReasons.Load()&pauseReasonUser != 0
+}
+
+// TogglePause 切换用户暂停，返回切换后是否处于暂停状态。
+func (p *Player) TogglePause() bool {
+	if p.UserPaused() {
+		p.Resume()
+		return false
+	}
+	p.Pause()
+	return true
+}
+
+// StepForward 暂停并显示下一帧，到最后一帧后回到第一帧。
+func (p *Player) StepForward() {
+	p.step(1)
+}
+
+// StepBackward 暂停并显示上一帧，到第一帧后回到最后一帧。
+func (p *Player) StepBackward() {
+	p.step(-1)
+}
+
+func (p *Player) step(delta int) {
+	p.Pause()
+
+	p.settingsMu.Lock()
+	anim := p.anim
+	id := p.animID
+	n := anim.frameCount()
+	if n == 0 || !p.isPlaybackActive(id) {
+		p.settingsMu.Unlock()
+		return
+	}
+	index := ((p.animIndex+delta)%n + n) % n
+	p.animIndex = index
+	p.animStepped = true
+	p.settingsMu.Unlock()
+
+	frame := p.renderFrame(id, index, anim.frameAt(index), anim.stream == nil)
+	fyne.Do(func() {
+		if !p.isPlaybackActive(id) {
+			return
+		}
+		p.Canvas.Image = frame
+		p.Canvas.Refresh()
+	})
+}
+
+// setAnimation 记录正在播放的动图，单帧步进时要用到。
+func (p *Player) setAnimation(playbackID uint64, anim *Animation) {
+	p.settingsMu.Lock()
+	p.anim = anim
+	p.animID = playbackID
+	p.animIndex = 0
+	if p.replayID == playbackID && p.replayIndex < anim.frameCount() {
+		p.animIndex = p.replayIndex
+	}
+	p.animStepped = false
+	p.settingsMu.Unlock()
+}
+
+// replayStart 返回重播时接着显示的帧，不是重播时 ok 为 false。
+func (p *Player) replayStart(playbackID uint64) (index int, ok bool) {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	if p.replayID != playbackID || p.animID != playbackID {
+		return 0, false
+	}
+	return p.animIndex, true
+}
+
+// showingFrame 在帧循环显示第 index 帧前调用。
+func (p *Player) showingFrame(playbackID uint64, index int) {
+	p.settingsMu.Lock()
+	if p.animID == playbackID {
+		p.animIndex = index
+	}
+	p.settingsMu.Unlock()
+}
+
+// takeSteppedFrame 返回暂停期间用户步进到的帧，没有步进过时 ok 为 false。
+func (p *Player) takeSteppedFrame(playbackID uint64) (index int, ok bool) {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	if p.animID != playbackID || !p.animStepped {
+		return 0, false
+	}
+	p.animStepped = false
+	return p.animIndex, true
+}
@@ -0,0 +1,152 @@
+package player
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestSetSpeed_Clamps(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   float64
+		want float64
+	}{
+		{in: 1, want: 1},
+		{in: 0.1, want: MinSpeed},
+		{in: 10, want: MaxSpeed},
+		{in: 0, want: 1},
+		{in: -2, want: 1},
+		{in: 2.5, want: 2.5},
+	}
+
+	for _, tc := range tests {
+		p := &Player{}
+		p.SetSpeed(tc.in)
+		if got := p.Speed(); got != tc.want {
+			t.Fatalf("SetSpeed(%v) -> %v, want %v", tc.in, got, tc.want)
+		}
+	}
+	if got := (&Player{}).Speed(); got != 1 {
+		t.Fatalf("default speed = %v, want 1", got)
+	}
+}
+
+func TestScaleFrameDelay(t *testing.T) {
+	t.Parallel()
+
+	if got := scaleFrameDelay(100*time.Millisecond, 2); got != 50*time.Millisecond {
+		t.Fatalf("2x delay = %v, want 50ms", got)
+	}
+	if got := scaleFrameDelay(100*time.Millisecond, 0.25); got != 400*time.Millisecond {
+		t.Fatalf("0.25x delay = %v, want 400ms", got)
+	}
+}
+
+func TestUserPause_IndependentOfOtherReasons(t *testing.T) {
+	t.Parallel()
+
+	p := &Player{pauseSignal: make(chan struct{}, 1)}
+	p.Pause()
+	p.SetRenderPaused(true)
+	p.SetRenderPaused(false)
+	if !p.RenderPaused() || !p.UserPaused() {
+		t.Fatalf("user pause should survive the end of a drag pause")
+	}
+	if p.TogglePause() {
+		t.Fatalf("TogglePause should resume a paused player")
+	}
+	if p.RenderPaused() {
+		t.Fatalf("player should be running after resume")
+	}
+}
+
+func TestStep_WrapsAroundAndResumesFromSteppedFrame(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	anim := &Animation{Width: 1, Height: 1}
+	for _, c := range colors {
+		img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		img.SetRGBA(0, 0, c)
+		anim.Frames = append(anim.Frames, img)
+		anim.Delays = append(anim.Delays, time.Second)
+	}
+
+	p := NewPlayer(a, w)
+	id := p.beginPlayback()
+	p.setAnimation(id, anim)
+
+	p.StepBackward()
+	fyne.DoAndWait(func() {})
+	if !p.UserPaused() {
+		t.Fatalf("stepping should pause playback")
+	}
+	assertRGBA(t, p.Canvas.Image.At(0, 0), colors[2])
+
+	p.StepForward()
+	fyne.DoAndWait(func() {})
+	assertRGBA(t, p.Canvas.Image.At(0, 0), colors[0])
+
+	index, ok := p.takeSteppedFrame(id)
+	if !ok || index != 0 {
+		t.Fatalf("takeSteppedFrame = %d, %v, want 0, true", index, ok)
+	}
+	if _, ok := p.takeSteppedFrame(id); ok {
+		t.Fatalf("stepped frame should only be reported once")
+	}
+}
+
+func TestPlay_ReplayKeepsUserPauseAndFrame(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	palette := color.Palette{colors[0], colors[1], colors[2]}
+	g := &gif.GIF{}
+	for i := range colors {
+		frame := image.NewPaletted(image.Rect(0, 0, 2, 2), palette)
+		for j := range frame.Pix {
+			frame.Pix[j] = uint8(i)
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 1000)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+	path := writeTestFile(t, "frames.gif", buf.Bytes())
+
+	p := NewPlayer(a, w)
+	p.Play(path)
+	p.StepForward()
+	// 编辑时改了裁剪等设置，同一张图重播
+	p.Play(path)
+	time.Sleep(100 * time.Millisecond)
+	p.Close()
+
+	if !p.UserPaused() {
+		t.Fatalf("replaying the same image should keep the user pause")
+	}
+	// 暂停着也要按新设置重新画出步进到的那一帧
+	p.renderMu.Lock()
+	shown := p.currentFrame
+	p.renderMu.Unlock()
+	if shown.playbackID != p.replayID || shown.index != 1 {
+		t.Fatalf("replay rendered frame %d of playback %d, want frame 1 of playback %d", shown.index, shown.playbackID, p.replayID)
+	}
+	assertRGBA(t, p.Canvas.Image.At(0, 0), colors[1])
+}
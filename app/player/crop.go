@@ -0,0 +1,134 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// 裁剪框的最小边长比例，太小的框视为误操作
+const minCropFraction = 0.01
+
+// CropRect 是显示的区域，坐标和宽高都是占原图的比例，零值表示显示整张图。
+type CropRect struct {
+	X, Y, W, H float64
+}
+
+func (c CropRect) IsFull() bool {
+	return c.normalize() == CropRect{}
+}
+
+func (c CropRect) normalize() CropRect {
+	c.X = clampFloat(c.X, 0, 1)
+	c.Y = clampFloat(c.Y, 0, 1)
+	c.W = clampFloat(c.W, 0, 1-c.X)
+	c.H = clampFloat(c.H, 0, 1-c.Y)
+	if c.W < minCropFraction || c.H < minCropFraction {
+		return CropRect{}
+	}
+	if c.X == 0 && c.Y == 0 && c.W == 1 && c.H == 1 {
+		return CropRect{}
+	}
+	return c
+}
+
+// Within 把相对于 c 所示区域的 inner 换算成相对于原图的裁剪框，用于在已裁剪的画面上再次裁剪。
+func (c CropRect) Within(inner CropRect) CropRect {
+	c = c.normalize()
+	if c == (CropRect{}) {
+		c = CropRect{W: 1, H: 1}
+	}
+	inner = inner.normalize()
+	if inner == (CropRect{}) {
+		return c.normalize()
+	}
+	return CropRect{
+		X: c.X + inner.X*c.W,
+		Y: c.Y + inner.Y*c.H,
+		W: inner.W * c.W,
+		H: inner.H * c.H,
+	}.normalize()
+}
+
+// pixels 返回裁剪框在 bounds 中的像素区域。
+func (c CropRect) pixels(bounds image.Rectangle) image.Rectangle {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	r := image.Rect(
+		int(math.Round(c.X*w)),
+		int(math.Round(c.Y*h)),
+		int(math.Round((c.X+c.W)*w)),
+		int(math.Round((c.Y+c.H)*h)),
+	).Add(bounds.Min).Intersect(bounds)
+	if r.Empty() {
+		return bounds
+	}
+	return r
+}
+
+// SetCrop 修改显示区域，当前画面立即重新渲染。窗口尺寸在下次播放时按裁剪后的尺寸更新。
+func (p *Player) SetCrop(c CropRect) {
+	c = c.normalize()
+	p.updateRender(func() bool {
+		if p.renderCrop == c {
+			return false
+		}
+		p.renderCrop = c
+		return true
+	})
+}
+
+func (p *Player) Crop() CropRect {
+	p.renderMu.Lock()
+	defer p.renderMu.Unlock()
+	return p.renderCrop
+}
+
+// croppedSize 返回 width x height 的画面裁剪后的尺寸。
+func croppedSize(width, height int, c CropRect) (int, int) {
+	if c.IsFull() {
+		return width, height
+	}
+	r := c.pixels(image.Rect(0, 0, width, height))
+	return r.Dx(), r.Dy()
+}
+
+// cropImage 返回裁剪后的图，不修改 src。矢量图裁剪后仍可按任意尺寸栅格化。
+func cropImage(src image.Image, c CropRect) image.Image {
+	if src == nil || c.IsFull() {
+		return src
+	}
+	if s, ok := src.(ScalableImage); ok {
+		return &croppedScalable{ScalableImage: s, crop: c, rect: c.pixels(src.Bounds())}
+	}
+	r := c.pixels(src.Bounds())
+	if sub, ok := src.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return copyToRGBA(sub.SubImage(r))
+	}
+	full := copyToRGBA(src)
+	return copyToRGBA(full.SubImage(r.Sub(src.Bounds().Min)))
+}
+
+// croppedScalable 是裁剪后的矢量图，按放大后的尺寸栅格化整张图再取出裁剪区域。
+type croppedScalable struct {
+	ScalableImage
+	crop CropRect
+	rect image.Rectangle
+}
+
+func (c *croppedScalable) Bounds() image.Rectangle {
+	return image.Rect(0, 0, c.rect.Dx(), c.rect.Dy())
+}
+
+func (c *croppedScalable) At(x, y int) color.Color {
+	return c.ScalableImage.At(c.rect.Min.X+x, c.rect.Min.Y+y)
+}
+
+func (c *croppedScalable) RenderAt(size image.Point) image.Image {
+	full := image.Pt(
+		int(math.Round(float64(size.X)/c.crop.W)),
+		int(math.Round(float64(size.Y)/c.crop.H)),
+	)
+	return cropImage(c.ScalableImage.RenderAt(full), c.crop)
+}
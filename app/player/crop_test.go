@@ -0,0 +1,92 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestCropRectNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   CropRect
+		want CropRect
+	}{
+		{"zero", CropRect{}, CropRect{}},
+		{"full", CropRect{W: 1, H: 1}, CropRect{}},
+		{"clamped", CropRect{X: 0.5, Y: -1, W: 2, H: 0.5}, CropRect{X: 0.5, W: 0.5, H: 0.5}},
+		{"too small", CropRect{X: 0.2, Y: 0.2, W: 0.001, H: 0.5}, CropRect{}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.in.normalize(); got != tt.want {
+				t.Fatalf("normalize(%+v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCropImage(t *testing.T) {
+	t.Parallel()
+
+	src := solidRGBA(10, 10, color.RGBA{255, 0, 0, 255})
+	for x := 5; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			src.SetRGBA(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+	out := cropImage(src, CropRect{X: 0.5, Y: 0, W: 0.5, H: 0.4})
+	if out.Bounds() != image.Rect(0, 0, 5, 4) {
+		t.Fatalf("cropped bounds = %v, want 5x4 at origin", out.Bounds())
+	}
+	assertRGBA(t, out.At(0, 0), color.RGBA{0, 0, 255, 255})
+	if cropImage(src, CropRect{}) != image.Image(src) {
+		t.Fatalf("full crop should return the source")
+	}
+	if w, h := croppedSize(10, 10, CropRect{X: 0.5, Y: 0, W: 0.5, H: 0.4}); w != 5 || h != 4 {
+		t.Fatalf("croppedSize = %dx%d, want 5x4", w, h)
+	}
+}
+
+func TestCroppedScalable_RendersOnlyTheCrop(t *testing.T) {
+	t.Parallel()
+
+	img, err := decodeSVG(strings.NewReader(`<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100">` +
+		`<rect width="50" height="100" fill="#ff0000"/><rect x="50" width="50" height="100" fill="#0000ff"/></svg>`))
+	if err != nil {
+		t.Fatalf("decodeSVG: %v", err)
+	}
+	out := cropImage(img, CropRect{X: 0.5, W: 0.5, H: 1})
+	if out.Bounds().Size() != image.Pt(50, 100) {
+		t.Fatalf("cropped svg bounds = %v", out.Bounds())
+	}
+	big := out.(ScalableImage).RenderAt(image.Pt(100, 200))
+	if big.Bounds().Size() != image.Pt(100, 200) {
+		t.Fatalf("RenderAt size = %v, want 100x200", big.Bounds().Size())
+	}
+	assertRGBA(t, big.At(50, 100), color.RGBA{0, 0, 255, 255})
+}
+
+func TestUpdateBaseSize_UsesCroppedSize(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	p := NewPlayer(a, w)
+	p.SetCrop(CropRect{X: 0.25, Y: 0, W: 0.5, H: 1})
+	fyne.DoAndWait(func() {
+		p.updateBaseSize(200, 100)
+	})
+	if p.baseSize != fyne.NewSize(100, 100) {
+		t.Fatalf("baseSize = %v, want 100x100", p.baseSize)
+	}
+}
@@ -0,0 +1,299 @@
+package player
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SniffHeaderSize 是嗅探格式时读取的文件头长度，传给 Decoder.Sniff 的数据不会超过这个长度。
+// SVG 这类文本格式开头常有 XML 声明和注释，要多读一些才能看到根元素。
+const SniffHeaderSize = 512
+
+// Decoder 描述一种可播放的图片格式。用 RegisterDecoder 注册后，
+// 文件选择器的过滤、文件夹扫描和播放都会自动支持这种格式。
+type Decoder interface {
+	// Name 是格式名，同名的解码器后注册的会替换先注册的。
+	Name() string
+	// Extensions 返回小写、不带点的扩展名，用于文件选择器过滤，以及内容无法识别时兜底。
+	Extensions() []string
+	// Sniff 根据文件头判断是否为该格式。
+	Sniff(header []byte) bool
+	// DecodeConfig 只读尺寸，解码前用它拒绝解码后过大的图片。
+	DecodeConfig(r io.Reader) (image.Config, error)
+	DecodeStill(r io.Reader) (image.Image, error)
+	// DecodeAnimation 在文件不是动图时返回 nil, nil，播放器会改用 DecodeStill。
+	DecodeAnimation(r io.Reader) (*Animation, error)
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = []Decoder{
+		pngDecoder{},
+		jpegDecoder{},
+		gifDecoder{},
+		webpDecoder{},
+		bmpDecoder{},
+		tiffDecoder{},
+		svgDecoder{},
+	}
+)
+
+// RegisterDecoder 注册一种新格式，或替换同名的已有格式。
+func RegisterDecoder(d Decoder) {
+	if d == nil {
+		return
+	}
+
+	// 已缓存的识别结果可能不再准确
+	defer resetDetectCache()
+
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	for i, existing := range decoders {
+		if existing.Name() == d.Name() {
+			decoders[i] = d
+			return
+		}
+	}
+	decoders = append(decoders, d)
+}
+
+func registeredDecoders() []Decoder {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	out := make([]Decoder, len(decoders))
+	copy(out, decoders)
+	return out
+}
+
+// SupportedExtensions 按注册顺序返回所有格式的扩展名（小写、不带点、去重）。
+func SupportedExtensions() []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, d := range registeredDecoders() {
+		for _, ext := range d.Extensions() {
+			ext = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+			if ext == "" {
+				continue
+			}
+			if _, ok := seen[ext]; ok {
+				continue
+			}
+			seen[ext] = struct{}{}
+			out = append(out, ext)
+		}
+	}
+	return out
+}
+
+func readFileHeader(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, SniffHeaderSize)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+// decoderByContent 找出认得这个文件头的格式，有几种都认得时优先选扩展名对得上的。
+func decoderByContent(header []byte, path string) (Decoder, bool) {
+	if len(header) == 0 {
+		return nil, false
+	}
+	var matched []Decoder
+	for _, d := range registeredDecoders() {
+		if d.Sniff(header) {
+			matched = append(matched, d)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, false
+	}
+	if d, ok := decoderByExtension(path, matched); ok {
+		return d, true
+	}
+	return matched[0], true
+}
+
+func decoderByExtension(path string, candidates []Decoder) (Decoder, bool) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if ext == "" {
+		return nil, false
+	}
+	for _, d := range candidates {
+		for _, e := range d.Extensions() {
+			if strings.TrimPrefix(strings.ToLower(e), ".") == ext {
+				return d, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// 最多缓存这么多个文件的识别结果，超过后清空重来
+const maxDetectCacheEntries = 4096
+
+type detectResult struct {
+	modTime    time.Time
+	size       int64
+	dirModTime time.Time
+	decoder    Decoder
+	// 精灵图的描述文件，没有时为空
+	sidecar string
+	ok      bool
+}
+
+// 文件夹扫描和监听会反复识别同一批文件，按路径缓存结果，修改时间或大小变了才重新读文件头。
+// 目录里增删文件时目录的修改时间会变，描述文件的增删也就会让缓存失效。
+var (
+	detectCacheMu sync.Mutex
+	detectCache   map[string]detectResult
+)
+
+func resetDetectCache() {
+	detectCacheMu.Lock()
+	detectCache = nil
+	detectCacheMu.Unlock()
+}
+
+// detectImage 按文件内容判断格式，扩展名只是提示：文件头读不出来时靠它兜底，
+// 几种格式都认得这个文件头时用它挑选。文件头读得出来但没有格式认得，就不能播放。
+// 浏览器和聊天软件经常用错误的扩展名保存图片。识别出图片时顺便找精灵图的描述文件。
+func detectImage(path string) detectResult {
+	info, err := os.Stat(path)
+	if err != nil {
+		d, ok := decoderByExtension(path, registeredDecoders())
+		return detectResult{decoder: d, ok: ok}
+	}
+	if info.IsDir() {
+		return detectResult{}
+	}
+	var dirModTime time.Time
+	if dir, err := os.Stat(filepath.Dir(path)); err == nil {
+		dirModTime = dir.ModTime()
+	}
+
+	detectCacheMu.Lock()
+	cached, hit := detectCache[path]
+	detectCacheMu.Unlock()
+	if hit && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() && cached.dirModTime.Equal(dirModTime) {
+		return cached
+	}
+
+	header, err := readFileHeader(path)
+	if err != nil {
+		d, ok := decoderByExtension(path, registeredDecoders())
+		return detectResult{decoder: d, ok: ok}
+	}
+	r := detectResult{modTime: info.ModTime(), size: info.Size(), dirModTime: dirModTime}
+	r.decoder, r.ok = decoderByContent(header, path)
+	if r.ok {
+		r.sidecar, _ = SpriteSheetSidecar(path)
+	}
+
+	detectCacheMu.Lock()
+	if detectCache == nil || len(detectCache) >= maxDetectCacheEntries {
+		detectCache = make(map[string]detectResult)
+	}
+	detectCache[path] = r
+	detectCacheMu.Unlock()
+	return r
+}
+
+func detectDecoder(path string) (Decoder, bool) {
+	r := detectImage(path)
+	return r.decoder, r.ok
+}
+
+// IsSupportedImageFile 判断文件内容是否为可播放的图片，文件读不出来时按扩展名判断。
+// 结果按路径缓存，文件夹扫描时重复检查同一个没改动的文件不会再打开它。
+func IsSupportedImageFile(path string) bool {
+	_, ok := detectDecoder(path)
+	return ok
+}
+
+// decodedImage 是解码好、等待显示的图片。anim 有帧时按动图播放，否则显示 still。
+type decodedImage struct {
+	anim  *Animation
+	still image.Image
+}
+
+// 解码后超过这个像素数的图片会占用过多内存，解码前按 DecodeConfig 读到的尺寸拒绝
+const maxImagePixels = 1 << 28
+
+var (
+	errUnsupportedImage = errors.New("unsupported image format")
+	errImageTooLarge    = errors.New("image too large")
+)
+
+// decodeImageFile 识别 path 的格式并解码，有精灵图描述文件时切成动图。
+func decodeImageFile(path string) (decodedImage, error) {
+	r := detectImage(path)
+	if !r.ok {
+		return decodedImage{}, errUnsupportedImage
+	}
+	if r.sidecar != "" {
+		return decodeSpriteSheet(r.decoder, path, r.sidecar)
+	}
+	return decodeFile(r.decoder, path)
+}
+
+// checkImageSize 只读文件头里的尺寸，读不出尺寸或图片过大时不再完整解码。
+func checkImageSize(d Decoder, data []byte) error {
+	cfg, err := d.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return fmt.Errorf("%w: %dx%d", errImageTooLarge, cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
+// decodeFile 用 d 解码 path。动画数据损坏时退回静态图，和浏览器的行为一致。
+func decodeFile(d Decoder, path string) (decodedImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return decodedImage{}, err
+	}
+	if err := checkImageSize(d, data); err != nil {
+		return decodedImage{}, err
+	}
+
+	anim, err := d.DecodeAnimation(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("decode %s animation failed, fallback to still image: %v", d.Name(), err)
+	}
+	if anim.frameCount() > 0 {
+		return decodedImage{anim: anim}, nil
+	}
+
+	img, err := d.DecodeStill(bytes.NewReader(data))
+	if err != nil {
+		return decodedImage{}, err
+	}
+	return decodedImage{still: img}, nil
+}
+
+func (img decodedImage) show(p *Player, playbackID uint64) {
+	if img.anim.frameCount() > 0 {
+		playAnimation(p, img.anim, playbackID)
+		return
+	}
+	showStillImage(p, img.still, playbackID)
+}
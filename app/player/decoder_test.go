@@ -0,0 +1,298 @@
+package player
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write %q: %v", name, err)
+	}
+	return path
+}
+
+func TestDetectDecoder_PrefersContentOverExtension(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		file     string
+		data     []byte
+		wantName string
+		wantOK   bool
+	}{
+		{name: "gif saved as jpg", file: "a.jpg", data: []byte("GIF89a....."), wantName: "gif", wantOK: true},
+		{name: "png without extension", file: "noext", data: []byte(pngSignature + "rest"), wantName: "png", wantOK: true},
+		{name: "webp saved as png", file: "b.png", data: []byte("RIFF\x10\x00\x00\x00WEBPVP8L"), wantName: "webp", wantOK: true},
+		{name: "jpeg with upper ext", file: "c.JPEG", data: []byte{0xff, 0xd8, 0xff, 0xe0}, wantName: "jpeg", wantOK: true},
+		{name: "bmp saved as jpg", file: "f.jpg", data: []byte("BM\x00\x00\x00\x00\x00\x00\x00\x00\x36\x00\x00\x00\x28\x00\x00\x00"), wantName: "bmp", wantOK: true},
+		{name: "text starting with BM", file: "g.txt", data: []byte("BMW is a car brand, not a bitmap"), wantOK: false},
+		{name: "tiff big endian", file: "h.bin", data: []byte("MM\x00*\x00\x00\x00\x08"), wantName: "tiff", wantOK: true},
+		{name: "svg without xml prolog", file: "i", data: []byte("  <svg xmlns=\"http://www.w3.org/2000/svg\">"), wantName: "svg", wantOK: true},
+		{name: "svg with xml prolog and comment", file: "j", data: []byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!-- Generator: Adobe Illustrator -->\n<svg xmlns=\"http://www.w3.org/2000/svg\">"), wantName: "svg", wantOK: true},
+		{name: "unknown content is rejected despite extension", file: "d.gif", data: []byte("x"), wantOK: false},
+		{name: "text named png", file: "note.png", data: []byte("just some notes"), wantOK: false},
+		{name: "truncated download named png", file: "part.png", data: []byte(pngSignature[:4]), wantOK: false},
+		{name: "empty file named jpg", file: "empty.jpg", data: nil, wantOK: false},
+		{name: "unknown content and extension", file: "e.txt", data: []byte("hello"), wantOK: false},
+		{name: "empty file without extension", file: "empty", data: nil, wantOK: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			path := writeTestFile(t, tc.file, tc.data)
+			d, ok := detectDecoder(path)
+			if ok != tc.wantOK {
+				t.Fatalf("detectDecoder ok = %v, want %v", ok, tc.wantOK)
+			}
+			if ok && d.Name() != tc.wantName {
+				t.Fatalf("detectDecoder name = %q, want %q", d.Name(), tc.wantName)
+			}
+			if got := IsSupportedImageFile(path); got != tc.wantOK {
+				t.Fatalf("IsSupportedImageFile = %v, want %v", got, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestDetectDecoder_MissingFileUsesExtension(t *testing.T) {
+	t.Parallel()
+
+	missing := filepath.Join(t.TempDir(), "gone.webp")
+	d, ok := detectDecoder(missing)
+	if !ok || d.Name() != "webp" {
+		t.Fatalf("missing file should fall back to extension, got ok=%v", ok)
+	}
+}
+
+// stubDecoder 是测试用的自定义格式：文件头为 "STUB"，内容为一个字节的宽度。
+type stubDecoder struct {
+	name     string
+	animated bool
+}
+
+func (d stubDecoder) Name() string { return d.name }
+
+func (stubDecoder) Extensions() []string { return []string{".STUB", "gif"} }
+
+func (stubDecoder) Sniff(header []byte) bool { return bytes.HasPrefix(header, []byte("STUB")) }
+
+func (stubDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil || len(data) < 5 {
+		return image.Config{}, io.ErrUnexpectedEOF
+	}
+	return image.Config{ColorModel: color.RGBAModel, Width: int(data[4]), Height: 1}, nil
+}
+
+func (d stubDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	c, err := d.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	return image.NewRGBA(image.Rect(0, 0, c.Width, c.Height)), nil
+}
+
+func (d stubDecoder) DecodeAnimation(r io.Reader) (*Animation, error) {
+	if !d.animated {
+		return nil, nil
+	}
+	c, err := d.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	frame := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	return &Animation{
+		Width:     c.Width,
+		Height:    c.Height,
+		Frames:    []image.Image{frame, frame},
+		Delays:    []time.Duration{time.Millisecond, time.Millisecond},
+		LoopCount: 1,
+	}, nil
+}
+
+func withTestDecoders(t *testing.T) {
+	t.Helper()
+	saved := registeredDecoders()
+	t.Cleanup(func() {
+		decodersMu.Lock()
+		decoders = saved
+		decodersMu.Unlock()
+		resetDetectCache()
+	})
+}
+
+func TestRegisterDecoder_AddsAndReplacesByName(t *testing.T) {
+	withTestDecoders(t)
+
+	before := len(registeredDecoders())
+	RegisterDecoder(nil)
+	RegisterDecoder(stubDecoder{name: "stub"})
+	RegisterDecoder(stubDecoder{name: "stub", animated: true})
+
+	all := registeredDecoders()
+	if len(all) != before+1 {
+		t.Fatalf("decoder count = %d, want %d", len(all), before+1)
+	}
+	if d, ok := all[len(all)-1].(stubDecoder); !ok || !d.animated {
+		t.Fatalf("same-name registration should replace the previous decoder")
+	}
+
+	path := writeTestFile(t, "custom.bin", []byte("STUB\x07"))
+	if !IsSupportedImageFile(path) {
+		t.Fatalf("content of registered decoder should be supported")
+	}
+	d, ok := detectDecoder(path)
+	if !ok || d.Name() != "stub" {
+		t.Fatalf("detectDecoder should pick the registered decoder")
+	}
+}
+
+// otherStubDecoder 和 stubDecoder 认同样的文件头，扩展名不同
+type otherStubDecoder struct{ stubDecoder }
+
+func (otherStubDecoder) Extensions() []string { return []string{"stub2"} }
+
+func TestDetectDecoder_ExtensionPicksAmongMatchingDecoders(t *testing.T) {
+	withTestDecoders(t)
+
+	RegisterDecoder(stubDecoder{name: "stub"})
+	RegisterDecoder(otherStubDecoder{stubDecoder{name: "stub2"}})
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: "a.stub2", want: "stub2"},
+		{file: "b.stub", want: "stub"},
+		{file: "c.bin", want: "stub"},
+	}
+	for _, tc := range tests {
+		path := writeTestFile(t, tc.file, []byte("STUB\x04"))
+		if d, ok := detectDecoder(path); !ok || d.Name() != tc.want {
+			t.Fatalf("detectDecoder(%q) = %v, %v, want %q", tc.file, d, ok, tc.want)
+		}
+	}
+}
+
+func TestDetectDecoder_CachesUntilFileChanges(t *testing.T) {
+	withTestDecoders(t)
+
+	path := writeTestFile(t, "cached.bin", []byte("GIF89a"))
+	if d, ok := detectDecoder(path); !ok || d.Name() != "gif" {
+		t.Fatalf("detectDecoder = %v, %v, want gif", d, ok)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 内容变了但大小和修改时间都没变，沿用缓存，说明没有再读文件
+	if err := os.WriteFile(path, []byte("hello!"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := detectDecoder(path); !ok || d.Name() != "gif" {
+		t.Fatalf("unchanged file should hit the cache, got %v, %v", d, ok)
+	}
+
+	if err := os.WriteFile(path, []byte("hello, world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := detectDecoder(path); ok {
+		t.Fatalf("changed file should be detected again")
+	}
+}
+
+func TestSupportedExtensions(t *testing.T) {
+	withTestDecoders(t)
+
+	want := []string{"png", "jpeg", "jpg", "gif", "webp", "bmp", "tiff", "tif", "svg"}
+	if got := SupportedExtensions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SupportedExtensions() = %v, want %v", got, want)
+	}
+
+	RegisterDecoder(stubDecoder{name: "stub"})
+	want = append(want, "stub")
+	if got := SupportedExtensions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SupportedExtensions() after register = %v, want %v", got, want)
+	}
+}
+
+func playDecoded(t *testing.T, p *Player, d Decoder, path string, playbackID uint64) {
+	t.Helper()
+	img, err := decodeFile(d, path)
+	if err != nil {
+		t.Fatalf("decodeFile: %v", err)
+	}
+	img.show(p, playbackID)
+}
+
+func TestDecodeFile_StillAndAnimation(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	path := writeTestFile(t, "custom.stub", []byte("STUB\x04"))
+
+	p := NewPlayer(a, w)
+	id := p.beginPlayback()
+	playDecoded(t, p, stubDecoder{name: "stub"}, path, id)
+	fyne.DoAndWait(func() {})
+	if p.Canvas.Image == nil || p.baseSize.Width != 4 {
+		t.Fatalf("still decoder should set image with width 4, got base %v", p.baseSize)
+	}
+
+	p.Canvas.Image = nil
+	id = p.beginPlayback()
+	playDecoded(t, p, stubDecoder{name: "stub", animated: true}, path, id)
+	// 动画播完一遍后帧循环退出，等它退出再读画面，避免和播放 goroutine 竞争
+	deadline := time.Now().Add(time.Second)
+	for p.running.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("animation should finish playing")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if p.Canvas.Image == nil {
+		t.Fatalf("animation frame should be shown")
+	}
+}
+
+// hugeDecoder 的文件头声称图片尺寸巨大
+type hugeDecoder struct{ stubDecoder }
+
+func (hugeDecoder) DecodeConfig(io.Reader) (image.Config, error) {
+	return image.Config{ColorModel: color.RGBAModel, Width: 1 << 15, Height: 1 << 15}, nil
+}
+
+func TestDecodeFile_RejectsTooLargeBeforeDecoding(t *testing.T) {
+	t.Parallel()
+
+	path := writeTestFile(t, "huge.stub", []byte("STUB\x04"))
+	if _, err := decodeFile(hugeDecoder{stubDecoder{name: "huge"}}, path); !errors.Is(err, errImageTooLarge) {
+		t.Fatalf("decodeFile error = %v, want errImageTooLarge", err)
+	}
+	if _, err := decodeFile(stubDecoder{name: "stub"}, path); err != nil {
+		t.Fatalf("small image should decode: %v", err)
+	}
+}
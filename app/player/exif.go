@@ -0,0 +1,160 @@
+package player
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"io"
+)
+
+const (
+	orientationNormal  = 1
+	exifTagOrientation = 0x0112
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// readJPEGOrientation 只读取 SOS 之前的 JPEG 头部段，找出 EXIF 里的 Orientation（1~8），
+// 读不到时返回 1。
+func readJPEGOrientation(r io.Reader) int {
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return orientationNormal
+	}
+
+	for {
+		b, err := br.ReadByte()
+		if err != nil || b != 0xff {
+			return orientationNormal
+		}
+		marker, err := br.ReadByte()
+		for err == nil && marker == 0xff {
+			marker, err = br.ReadByte()
+		}
+		if err != nil {
+			return orientationNormal
+		}
+		switch {
+		case marker == 0xda || marker == 0xd9:
+			// 到了图像数据，后面不会再有 EXIF
+			return orientationNormal
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			continue
+		}
+
+		var size [2]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return orientationNormal
+		}
+		n := int(binary.BigEndian.Uint16(size[:])) - 2
+		if n < 0 {
+			return orientationNormal
+		}
+		if marker != 0xe1 {
+			if _, err := br.Discard(n); err != nil {
+				return orientationNormal
+			}
+			continue
+		}
+
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return orientationNormal
+		}
+		if bytes.HasPrefix(payload, exifHeader) {
+			return parseExifOrientation(payload[len(exifHeader):])
+		}
+	}
+}
+
+// parseExifOrientation 从 EXIF 的 TIFF 结构里读 IFD0 的 Orientation。
+func parseExifOrientation(data []byte) int {
+	if len(data) < 8 {
+		return orientationNormal
+	}
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte("II*\x00")):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte("MM\x00*")):
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+
+	ifd := int64(order.Uint32(data[4:8]))
+	if ifd+2 > int64(len(data)) {
+		return orientationNormal
+	}
+	count := int64(order.Uint16(data[ifd : ifd+2]))
+	for i := int64(0); i < count; i++ {
+		at := ifd + 2 + i*12
+		if at+12 > int64(len(data)) {
+			break
+		}
+		entry := data[at : at+12]
+		if order.Uint16(entry[0:2]) != exifTagOrientation {
+			continue
+		}
+		v := int(order.Uint16(entry[8:10]))
+		if v >= 1 && v <= 8 {
+			return v
+		}
+		break
+	}
+	return orientationNormal
+}
+
+// orientationSwapsSize 判断该方向显示时宽高是否互换。
+func orientationSwapsSize(orientation int) bool {
+	return orientation >= 5 && orientation <= 8
+}
+
+// applyOrientation 按 EXIF Orientation 旋转或翻转图片，返回摆正后的图片。
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if img == nil || orientation <= orientationNormal || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	src, ok := img.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		src = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientationSwapsSize(orientation) {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
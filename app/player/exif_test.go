@@ -0,0 +1,134 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// buildTestJPEGWithOrientation 生成一张 JPEG，并在 SOI 后插入带 Orientation 的 EXIF 段。
+func buildTestJPEGWithOrientation(t *testing.T, w, h int, orientation uint16, order binary.ByteOrder) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	raw := buf.Bytes()
+
+	tiffData := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiffData, "II*\x00")
+	} else {
+		copy(tiffData, "MM\x00*")
+	}
+	order.PutUint32(tiffData[4:8], 8)
+	order.PutUint16(tiffData[8:10], 1)
+	entry := tiffData[10:22]
+	order.PutUint16(entry[0:2], exifTagOrientation)
+	order.PutUint16(entry[2:4], 3)
+	order.PutUint32(entry[4:8], 1)
+	order.PutUint16(entry[8:10], orientation)
+
+	payload := append(append([]byte(nil), exifHeader...), tiffData...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte(nil), raw[:2]...)
+	out = append(out, segment...)
+	return append(out, raw[2:]...)
+}
+
+func TestReadJPEGOrientation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "little endian rotate 90", data: buildTestJPEGWithOrientation(t, 4, 2, 6, binary.LittleEndian), want: 6},
+		{name: "big endian flip", data: buildTestJPEGWithOrientation(t, 4, 2, 2, binary.BigEndian), want: 2},
+		{name: "invalid value", data: buildTestJPEGWithOrientation(t, 4, 2, 42, binary.LittleEndian), want: 1},
+		{name: "not a jpeg", data: []byte("hello"), want: 1},
+		{name: "truncated", data: []byte{0xff, 0xd8, 0xff, 0xe1, 0x00}, want: 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := readJPEGOrientation(bytes.NewReader(tc.data)); got != tc.want {
+				t.Fatalf("readJPEGOrientation = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJPEGDecoder_AppliesOrientation(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestJPEGWithOrientation(t, 4, 2, 6, binary.LittleEndian)
+
+	cfg, err := jpegDecoder{}.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeConfig error: %v", err)
+	}
+	if cfg.Width != 2 || cfg.Height != 4 {
+		t.Fatalf("config = %dx%d, want 2x4", cfg.Width, cfg.Height)
+	}
+
+	img, err := jpegDecoder{}.DecodeStill(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeStill error: %v", err)
+	}
+	if img.Bounds().Size() != image.Pt(2, 4) {
+		t.Fatalf("decoded size = %v, want (2,4)", img.Bounds().Size())
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	t.Parallel()
+
+	// 3x2 的图，每个像素的 R 是序号：
+	// 0 1 2
+	// 3 4 5
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		src.SetRGBA(i%3, i/3, color.RGBA{uint8(i), 0, 0, 255})
+	}
+
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{orientation: 1, want: [][]uint8{{0, 1, 2}, {3, 4, 5}}},
+		{orientation: 2, want: [][]uint8{{2, 1, 0}, {5, 4, 3}}},
+		{orientation: 3, want: [][]uint8{{5, 4, 3}, {2, 1, 0}}},
+		{orientation: 4, want: [][]uint8{{3, 4, 5}, {0, 1, 2}}},
+		{orientation: 5, want: [][]uint8{{0, 3}, {1, 4}, {2, 5}}},
+		{orientation: 6, want: [][]uint8{{3, 0}, {4, 1}, {5, 2}}},
+		{orientation: 7, want: [][]uint8{{5, 2}, {4, 1}, {3, 0}}},
+		{orientation: 8, want: [][]uint8{{2, 5}, {1, 4}, {0, 3}}},
+	}
+
+	for _, tc := range tests {
+		got := applyOrientation(src, tc.orientation)
+		if got.Bounds().Dx() != len(tc.want[0]) || got.Bounds().Dy() != len(tc.want) {
+			t.Fatalf("orientation %d size = %v", tc.orientation, got.Bounds().Size())
+		}
+		for y, row := range tc.want {
+			for x, v := range row {
+				r, _, _, _ := got.At(x, y).RGBA()
+				if uint8(r>>8) != v {
+					t.Fatalf("orientation %d pixel (%d,%d) = %d, want %d", tc.orientation, x, y, r>>8, v)
+				}
+			}
+		}
+	}
+}
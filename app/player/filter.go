@@ -0,0 +1,194 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+const MaxFilterBlur = 20.0
+
+// FilterSettings 是显示前对画面做的调色和模糊，零值表示原样显示。
+type FilterSettings struct {
+	Grayscale bool
+	Sepia     bool
+	// Blur 是高斯模糊的 sigma，单位是屏幕像素
+	Blur float64
+	// Brightness、Contrast、Saturation 取值 -1~1，0 表示不变
+	Brightness float64
+	Contrast   float64
+	Saturation float64
+	// Tint 是叠加的颜色，A 表示强度
+	Tint color.NRGBA
+}
+
+func (s FilterSettings) IsIdentity() bool {
+	return s.normalize() == FilterSettings{}
+}
+
+func (s FilterSettings) normalize() FilterSettings {
+	s.Blur = clampFloat(s.Blur, 0, MaxFilterBlur)
+	s.Brightness = clampFloat(s.Brightness, -1, 1)
+	s.Contrast = clampFloat(s.Contrast, -1, 1)
+	s.Saturation = clampFloat(s.Saturation, -1, 1)
+	if s.Tint.A == 0 {
+		s.Tint = color.NRGBA{}
+	}
+	return s
+}
+
+func clampFloat(v, lo, hi float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return min(max(v, lo), hi)
+}
+
+// SetFilters 修改画面滤镜，当前画面立即按新滤镜重新渲染。
+func (p *Player) SetFilters(s FilterSettings) {
+	s = s.normalize()
+	p.updateRender(func() bool {
+		if p.renderFilters == s {
+			return false
+		}
+		p.renderFilters = s
+		return true
+	})
+}
+
+func (p *Player) Filters() FilterSettings {
+	p.renderMu.Lock()
+	defer p.renderMu.Unlock()
+	return p.renderFilters
+}
+
+// applyFilters 返回处理后的新图，不修改 src。
+func applyFilters(src image.Image, s FilterSettings) image.Image {
+	s = s.normalize()
+	if s == (FilterSettings{}) || src == nil {
+		return src
+	}
+
+	dst := copyToRGBA(src)
+	if s.Blur > 0 {
+		gaussianBlur(dst, s.Blur)
+	}
+	if s.hasColorAdjust() {
+		adjustColors(dst, s)
+	}
+	return dst
+}
+
+// copyToRGBA 把 src 复制成从 (0, 0) 开始的 RGBA，之后可以原地修改像素。
+func copyToRGBA(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+func (s FilterSettings) hasColorAdjust() bool {
+	s.Blur = 0
+	return s != FilterSettings{}
+}
+
+// adjustColors 依次做亮度、对比度、饱和度、灰度、怀旧和叠色。
+func adjustColors(img *image.RGBA, s FilterSettings) {
+	tintA := float64(s.Tint.A) / 255
+	tint := [3]float64{float64(s.Tint.R) / 255, float64(s.Tint.G) / 255, float64(s.Tint.B) / 255}
+	pix := img.Pix
+	for i := 0; i+3 < len(pix); i += 4 {
+		a := float64(pix[i+3]) / 255
+		if a == 0 {
+			continue
+		}
+		// 像素是预乘过 alpha 的，先还原再调色
+		c := [3]float64{
+			float64(pix[i]) / 255 / a,
+			float64(pix[i+1]) / 255 / a,
+			float64(pix[i+2]) / 255 / a,
+		}
+		for k := range c {
+			c[k] *= 1 + s.Brightness
+			c[k] = (c[k]-0.5)*(1+s.Contrast) + 0.5
+		}
+		if s.Saturation != 0 {
+			l := luma(c)
+			for k := range c {
+				c[k] = l + (c[k]-l)*(1+s.Saturation)
+			}
+		}
+		if s.Grayscale {
+			l := luma(c)
+			c = [3]float64{l, l, l}
+		}
+		if s.Sepia {
+			c = [3]float64{
+				0.393*c[0] + 0.769*c[1] + 0.189*c[2],
+				0.349*c[0] + 0.686*c[1] + 0.168*c[2],
+				0.272*c[0] + 0.534*c[1] + 0.131*c[2],
+			}
+		}
+		if tintA > 0 {
+			for k := range c {
+				c[k] = c[k]*(1-tintA) + tint[k]*tintA
+			}
+		}
+		for k := range c {
+			pix[i+k] = uint8(math.Round(clampFloat(c[k], 0, 1) * a * 255))
+		}
+	}
+}
+
+func luma(c [3]float64) float64 {
+	return 0.299*c[0] + 0.587*c[1] + 0.114*c[2]
+}
+
+// gaussianBlur 原地做可分离的高斯模糊，边缘像素向外延伸。
+func gaussianBlur(img *image.RGBA, sigma float64) {
+	radius := int(math.Ceil(sigma * 3))
+	if radius < 1 {
+		return
+	}
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	tmp := make([]uint8, len(img.Pix))
+	blurPass(tmp, img.Pix, img.Stride, w, h, kernel, radius, true)
+	blurPass(img.Pix, tmp, img.Stride, w, h, kernel, radius, false)
+}
+
+func blurPass(dst, src []uint8, stride, w, h int, kernel []float64, radius int, horizontal bool) {
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var acc [4]float64
+			for k, weight := range kernel {
+				sx, sy := x, y
+				if horizontal {
+					sx = min(max(x+k-radius, 0), w-1)
+				} else {
+					sy = min(max(y+k-radius, 0), h-1)
+				}
+				o := sy*stride + sx*4
+				acc[0] += float64(src[o]) * weight
+				acc[1] += float64(src[o+1]) * weight
+				acc[2] += float64(src[o+2]) * weight
+				acc[3] += float64(src[o+3]) * weight
+			}
+			o := y*stride + x*4
+			for c := range acc {
+				dst[o+c] = uint8(math.Round(min(acc[c], 255)))
+			}
+		}
+	}
+}
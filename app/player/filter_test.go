@@ -0,0 +1,84 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestApplyFilters(t *testing.T) {
+	t.Parallel()
+
+	src := solidRGBA(4, 4, color.RGBA{200, 100, 50, 255})
+	tests := []struct {
+		name string
+		s    FilterSettings
+		want color.RGBA
+	}{
+		{"identity", FilterSettings{}, color.RGBA{200, 100, 50, 255}},
+		{"grayscale", FilterSettings{Grayscale: true}, color.RGBA{124, 124, 124, 255}},
+		{"darker", FilterSettings{Brightness: -0.5}, color.RGBA{100, 50, 25, 255}},
+		{"desaturate", FilterSettings{Saturation: -1}, color.RGBA{124, 124, 124, 255}},
+		{"full tint", FilterSettings{Tint: color.NRGBA{0, 0, 255, 255}}, color.RGBA{0, 0, 255, 255}},
+		{"blur of flat color", FilterSettings{Blur: 2}, color.RGBA{200, 100, 50, 255}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assertRGBA(t, applyFilters(src, tt.s).At(1, 1), tt.want)
+		})
+	}
+	assertRGBA(t, src.At(1, 1), color.RGBA{200, 100, 50, 255})
+}
+
+func TestApplyFilters_KeepsTransparentPixels(t *testing.T) {
+	t.Parallel()
+
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, color.RGBA{100, 0, 0, 128})
+	out := applyFilters(src, FilterSettings{Brightness: 1, Sepia: true})
+	assertRGBA(t, out.At(1, 1), color.RGBA{})
+	if r, _, _, a := out.At(0, 0).RGBA(); a>>8 != 128 || r>>8 > 128 {
+		t.Fatalf("premultiplied pixel escaped its alpha: r%d a%d", r>>8, a>>8)
+	}
+}
+
+func TestGaussianBlur_SpreadsEdges(t *testing.T) {
+	t.Parallel()
+
+	src := solidRGBA(10, 1, color.RGBA{0, 0, 0, 255})
+	for x := 5; x < 10; x++ {
+		src.SetRGBA(x, 0, color.RGBA{255, 255, 255, 255})
+	}
+	out := applyFilters(src, FilterSettings{Blur: 1.5})
+	r4, _, _, _ := out.At(4, 0).RGBA()
+	r5, _, _, _ := out.At(5, 0).RGBA()
+	if r4>>8 == 0 || r5>>8 == 255 || r4 >= r5 {
+		t.Fatalf("edge was not softened: %d, %d", r4>>8, r5>>8)
+	}
+}
+
+func TestSetFilters_InvalidatesRenderCache(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	p := NewPlayer(a, w)
+	id := p.beginPlayback()
+	src := solidRGBA(8, 8, color.RGBA{200, 100, 50, 255})
+	first := p.renderFrame(id, 0, src, true)
+	if p.renderFrame(id, 0, src, true) != first {
+		t.Fatalf("second render should hit the cache")
+	}
+
+	p.SetFilters(FilterSettings{Grayscale: true})
+	if len(p.renderCache) != 0 {
+		t.Fatalf("changing filters should drop the cache")
+	}
+	assertRGBA(t, p.renderFrame(id, 0, src, true).At(0, 0), color.RGBA{124, 124, 124, 255})
+	p.Close()
+}
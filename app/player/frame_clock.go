@@ -0,0 +1,50 @@
+package player
+
+import "time"
+
+// 落后超过这个时长（比如系统休眠后恢复）就不再追帧，直接从当前时刻重新计时
+const maxFrameClockLag = 5 * time.Second
+
+// frameClock 按单调时钟安排每一帧的显示时刻。每帧的时刻 = 起始时刻 + 之前所有帧的时长，
+// 渲染和调度的耗时不会累积，落后时跳帧追上，而不是拉长整段动画。
+type frameClock struct {
+	now func() time.Time
+	// sleep 返回 false 表示播放已被取消
+	sleep func(time.Duration) bool
+	// 当前帧应当开始显示的时刻
+	next time.Time
+}
+
+func newFrameClock(now func() time.Time, sleep func(time.Duration) bool) *frameClock {
+	return &frameClock{now: now, sleep: sleep, next: now()}
+}
+
+// skip 判断时长为 delay 的当前帧是否已经整个过期。过期时时钟前进到下一帧并返回 true，调用方应跳过这一帧。
+func (c *frameClock) skip(delay time.Duration) bool {
+	lag := c.now().Sub(c.next)
+	if lag > maxFrameClockLag {
+		c.next = c.now()
+		return false
+	}
+	if lag < delay {
+		return false
+	}
+	c.next = c.next.Add(delay)
+	return true
+}
+
+// wait 在当前帧显示 delay 之后返回，时钟前进到下一帧。播放被取消时返回 false。
+func (c *frameClock) wait(delay time.Duration) bool {
+	c.next = c.next.Add(delay)
+	if d := c.next.Sub(c.now()); d > 0 {
+		return c.sleep(d)
+	}
+	return true
+}
+
+// shift 把之后所有帧的时刻推迟 d，用于暂停后恢复。
+func (c *frameClock) shift(d time.Duration) {
+	if d > 0 {
+		c.next = c.next.Add(d)
+	}
+}
@@ -0,0 +1,104 @@
+package player
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeFrameTime struct {
+	t time.Time
+}
+
+func (f *fakeFrameTime) now() time.Time { return f.t }
+
+func (f *fakeFrameTime) sleep(d time.Duration) bool {
+	f.t = f.t.Add(d)
+	return true
+}
+
+func TestFrameClock_RenderCostDoesNotAccumulate(t *testing.T) {
+	t.Parallel()
+
+	ft := &fakeFrameTime{t: time.Unix(0, 0)}
+	start := ft.t
+	c := newFrameClock(ft.now, ft.sleep)
+
+	const frames = 600
+	delay := 50 * time.Millisecond
+	for i := 0; i < frames; i++ {
+		if c.skip(delay) {
+			t.Fatalf("frame %d should not be skipped when rendering is fast", i)
+		}
+		ft.t = ft.t.Add(7 * time.Millisecond) // 渲染耗时
+		c.wait(delay)
+	}
+
+	if got, want := ft.t.Sub(start), frames*delay; got != want {
+		t.Fatalf("elapsed = %v, want %v", got, want)
+	}
+}
+
+func TestFrameClock_SkipsFramesWhenBehind(t *testing.T) {
+	t.Parallel()
+
+	ft := &fakeFrameTime{t: time.Unix(0, 0)}
+	start := ft.t
+	c := newFrameClock(ft.now, ft.sleep)
+
+	delay := 20 * time.Millisecond
+	shown, skipped := 0, 0
+	for i := 0; i < 100; i++ {
+		if c.skip(delay) {
+			skipped++
+			continue
+		}
+		shown++
+		ft.t = ft.t.Add(50 * time.Millisecond) // 渲染比帧间隔慢
+		c.wait(delay)
+	}
+
+	if skipped == 0 {
+		t.Fatalf("expected frames to be skipped")
+	}
+	// 100 帧共 2 秒，跳帧后总时长不应被拉长
+	if got := ft.t.Sub(start); got > 2*time.Second+50*time.Millisecond {
+		t.Fatalf("elapsed = %v, animation was stretched (shown=%d skipped=%d)", got, shown, skipped)
+	}
+}
+
+func TestFrameClock_ShiftKeepsScheduleAfterPause(t *testing.T) {
+	t.Parallel()
+
+	ft := &fakeFrameTime{t: time.Unix(0, 0)}
+	c := newFrameClock(ft.now, ft.sleep)
+	delay := 100 * time.Millisecond
+
+	c.wait(delay)
+	pause := 3 * time.Second
+	ft.t = ft.t.Add(pause)
+	c.shift(pause)
+
+	if c.skip(delay) {
+		t.Fatalf("frame right after a pause should not be skipped")
+	}
+	before := ft.t
+	c.wait(delay)
+	if got := ft.t.Sub(before); got != delay {
+		t.Fatalf("wait after pause = %v, want %v", got, delay)
+	}
+}
+
+func TestFrameClock_ResyncsAfterLongStall(t *testing.T) {
+	t.Parallel()
+
+	ft := &fakeFrameTime{t: time.Unix(0, 0)}
+	c := newFrameClock(ft.now, ft.sleep)
+	ft.t = ft.t.Add(time.Hour)
+
+	if c.skip(10 * time.Millisecond) {
+		t.Fatalf("clock should resync instead of skipping after a long stall")
+	}
+	if !c.next.Equal(ft.t) {
+		t.Fatalf("next = %v, want %v", c.next, ft.t)
+	}
+}
@@ -1,72 +1,73 @@
 package player
 
 import (
+	"bytes"
 	"image"
-	"image/color"
 	"image/draw"
 	"image/gif"
-	"log"
-	"os"
+	"io"
 	"time"
-
-	"fyne.io/fyne/v2"
 )
 
-const minGIFFrameDelay = time.Second / 60
+const minGIFFrameDelay = minFrameDelay
 
-func PlayGIF(p *Player, path string, playbackID uint64) {
-	f, err := os.Open(path)
-	if err != nil {
-		log.Printf("open gif failed: %v", err)
-		return
-	}
-	defer f.Close()
+type gifDecoder struct{}
+
+func (gifDecoder) Name() string { return "gif" }
+
+func (gifDecoder) Extensions() []string { return []string{"gif"} }
+
+func (gifDecoder) Sniff(header []byte) bool {
+	return bytes.HasPrefix(header, []byte("GIF87a")) || bytes.HasPrefix(header, []byte("GIF89a"))
+}
+
+func (gifDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	return gif.DecodeConfig(r)
+}
 
-	g, err := gif.DecodeAll(f)
+func (gifDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	return gif.Decode(r)
+}
+
+func (gifDecoder) DecodeAnimation(r io.Reader) (*Animation, error) {
+	g, err := gif.DecodeAll(r)
 	if err != nil {
-		log.Printf("decode gif failed: %v", err)
-		return
+		return nil, err
+	}
+	if len(g.Image) <= 1 {
+		return nil, nil
 	}
 
-	frames := composeGIFFrames(g)
-	if len(frames) == 0 {
-		return
+	delays := make([]time.Duration, len(g.Image))
+	for i := range g.Image {
+		delays[i] = normalizedGIFFrameDelay(g, i)
 	}
-	if !p.isPlaybackActive(playbackID) {
-		return
+	anim := &Animation{
+		Width:     g.Config.Width,
+		Height:    g.Config.Height,
+		Delays:    delays,
+		LoopCount: gifPlayCount(g.LoopCount),
 	}
 
-	fyne.Do(func() {
-		if !p.isPlaybackActive(playbackID) {
-			return
-		}
-		p.updateBaseSize(g.Config.Width, g.Config.Height)
-	})
-
-	go func(currentID uint64) {
-		for p.isPlaybackActive(currentID) {
-			for i := range frames {
-				if !p.isPlaybackActive(currentID) {
-					return
-				}
-				if !p.waitRenderResumed(currentID) {
-					return
-				}
-
-				frame := frames[i]
-				delay := normalizedGIFFrameDelay(g, i)
-
-				fyne.Do(func() {
-					if !p.isPlaybackActive(currentID) {
-						return
-					}
-					p.Canvas.Image = frame
-					p.Canvas.Refresh()
-				})
-				time.Sleep(delay)
-			}
-		}
-	}(playbackID)
+	// 帧多、尺寸大的 GIF 预合成会占用大量内存，改为播放时按需合成
+	if opts := currentStreamingOptions(); shouldStreamGIF(g, opts) {
+		anim.stream = newGIFStream(g, opts.RingSize, opts.ThresholdBytes/streamingKeyframeShare)
+		return anim, nil
+	}
+	anim.Frames = composeGIFFrames(g)
+	return anim, nil
+}
+
+// gifPlayCount 把 gif.GIF.LoopCount（重复次数，-1 表示只播一遍）换算成总播放次数。
+func gifPlayCount(loopCount int) int {
+	switch {
+	case loopCount < 0:
+		return 1
+	case loopCount == 0:
+		return 0
+	default:
+		return loopCount + 1
+	}
 }
 
 func normalizedGIFFrameDelay(g *gif.GIF, frameIndex int) time.Duration {
@@ -74,10 +75,7 @@ func normalizedGIFFrameDelay(g *gif.GIF, frameIndex int) time.Duration {
 	if g != nil && frameIndex >= 0 && frameIndex < len(g.Delay) && g.Delay[frameIndex] > 0 {
 		delay = time.Duration(g.Delay[frameIndex]) * 10 * time.Millisecond
 	}
-	if delay < minGIFFrameDelay {
-		return minGIFFrameDelay
-	}
-	return delay
+	return normalizeFrameDelay(delay)
 }
 
 func composeGIFFrames(g *gif.GIF) []image.Image {
@@ -85,37 +83,65 @@ func composeGIFFrames(g *gif.GIF) []image.Image {
 		return nil
 	}
 
+	c := newGIFCompositor(g)
+	frames := make([]image.Image, 0, len(g.Image))
+	for c.next < len(g.Image) {
+		c.step()
+		frames = append(frames, cloneRGBA(c.canvas, c.bounds))
+	}
+	return frames
+}
+
+// gifCompositor 按顺序把 GIF 的调色板帧合成到同一张画布上，处理各帧的 disposal。
+type gifCompositor struct {
+	g       *gif.GIF
+	bounds  image.Rectangle
+	canvas  *image.RGBA
+	restore *image.RGBA
+	// 下一帧要合成的序号
+	next int
+}
+
+func newGIFCompositor(g *gif.GIF) *gifCompositor {
 	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
-	canvas := image.NewRGBA(bounds)
-	transparent := image.NewUniform(color.Transparent)
+	return &gifCompositor{
+		g:      g,
+		bounds: bounds,
+		canvas: image.NewRGBA(bounds),
+	}
+}
 
-	var restore *image.RGBA
-	frames := make([]image.Image, 0, len(g.Image))
+func (c *gifCompositor) reset() {
+	clear(c.canvas.Pix)
+	c.next = 0
+}
 
-	for i, src := range g.Image {
-		if i > 0 {
-			prev := g.Image[i-1]
-			switch disposalAt(g, i-1) {
-			case gif.DisposalBackground:
-				draw.Draw(canvas, prev.Bounds(), transparent, image.Point{}, draw.Src)
-			case gif.DisposalPrevious:
-				if restore != nil {
-					draw.Draw(canvas, bounds, restore, bounds.Min, draw.Src)
-				}
+// step 合成第 next 帧，结果留在 canvas 上。
+func (c *gifCompositor) step() {
+	g := c.g
+	i := c.next
+	if i > 0 {
+		prev := g.Image[i-1]
+		switch disposalAt(g, i-1) {
+		case gif.DisposalBackground:
+			draw.Draw(c.canvas, prev.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			if c.restore != nil {
+				copy(c.canvas.Pix, c.restore.Pix)
 			}
 		}
+	}
 
-		if disposalAt(g, i) == gif.DisposalPrevious {
-			restore = cloneRGBA(canvas, bounds)
-		} else {
-			restore = nil
+	if disposalAt(g, i) == gif.DisposalPrevious {
+		if c.restore == nil {
+			c.restore = image.NewRGBA(c.bounds)
 		}
-
-		draw.Draw(canvas, src.Bounds(), src, src.Bounds().Min, draw.Over)
-		frames = append(frames, cloneRGBA(canvas, bounds))
+		copy(c.restore.Pix, c.canvas.Pix)
 	}
 
-	return frames
+	src := g.Image[i]
+	draw.Draw(c.canvas, src.Bounds(), src, src.Bounds().Min, draw.Over)
+	c.next++
 }
 
 func disposalAt(g *gif.GIF, i int) byte {
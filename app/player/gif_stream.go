@@ -0,0 +1,197 @@
+package player
+
+import (
+	"image"
+	"image/gif"
+	"sync"
+)
+
+const (
+	defaultStreamingThresholdBytes = 256 << 20
+	defaultStreamingRingSize       = 8
+	// 往回步进时环形缓冲里至少还有前面几帧
+	minStreamingRingSize = 3
+	// 关键帧占用的内存最多是流式阈值的这个比例
+	streamingKeyframeShare = 4
+)
+
+// StreamingOptions 控制大 GIF 的流式合成。
+type StreamingOptions struct {
+	// ThresholdBytes 预合成全部帧所需的内存超过这个值时改为按需合成，<=0 表示总是预合成。
+	// 多页 TIFF 解码全部页面超过这个值时只显示第一页。
+	ThresholdBytes int64
+	// RingSize 按需合成时最多保留的已合成帧数。
+	RingSize int
+}
+
+var (
+	streamingMu   sync.Mutex
+	streamingOpts = StreamingOptions{
+		ThresholdBytes: defaultStreamingThresholdBytes,
+		RingSize:       defaultStreamingRingSize,
+	}
+)
+
+// SetStreamingOptions 修改之后解码的 GIF 的流式合成参数，不影响正在播放的动图。
+func SetStreamingOptions(opts StreamingOptions) {
+	if opts.RingSize < minStreamingRingSize {
+		opts.RingSize = minStreamingRingSize
+	}
+	streamingMu.Lock()
+	streamingOpts = opts
+	streamingMu.Unlock()
+}
+
+func currentStreamingOptions() StreamingOptions {
+	streamingMu.Lock()
+	defer streamingMu.Unlock()
+	return streamingOpts
+}
+
+// composedGIFBytes 估算把所有帧预合成为 RGBA 需要的内存。
+func composedGIFBytes(g *gif.GIF) int64 {
+	if g == nil {
+		return 0
+	}
+	return int64(g.Config.Width) * int64(g.Config.Height) * 4 * int64(len(g.Image))
+}
+
+func shouldStreamGIF(g *gif.GIF, opts StreamingOptions) bool {
+	return opts.ThresholdBytes > 0 && composedGIFBytes(g) > opts.ThresholdBytes
+}
+
+// gifStream 只保存解码后的调色板帧，播放时按需合成，最近合成的帧放在环形缓冲里。
+// 每隔 keyframeEvery 帧保存一次合成状态，往回跳时从最近的关键帧继续合成，不用从头开始。
+type gifStream struct {
+	mu   sync.Mutex
+	comp *gifCompositor
+	ring []*image.RGBA
+	// ringIndex[k] 是 ring[k] 中保存的帧序号，-1 表示空
+	ringIndex []int
+
+	keyframeEvery int
+	// keyframes[k] 是合成第 k*keyframeEvery 帧之前的状态，nil 表示还没保存
+	keyframes []*gifKeyframe
+}
+
+type gifKeyframe struct {
+	canvas  *image.RGBA
+	restore *image.RGBA
+}
+
+// newGIFStream 创建流式合成，关键帧总共最多占用 keyframeBytes 字节。
+func newGIFStream(g *gif.GIF, ringSize int, keyframeBytes int64) *gifStream {
+	if ringSize < minStreamingRingSize {
+		ringSize = minStreamingRingSize
+	}
+	if ringSize > len(g.Image) {
+		ringSize = len(g.Image)
+	}
+	s := &gifStream{
+		comp:      newGIFCompositor(g),
+		ring:      make([]*image.RGBA, ringSize),
+		ringIndex: make([]int, ringSize),
+	}
+	for i := range s.ringIndex {
+		s.ringIndex[i] = -1
+	}
+
+	// 关键帧间隔不小于环形缓冲，往回播放时合成出来的帧正好填满缓冲
+	s.keyframeEvery = max(ringSize, 1)
+	if perKeyframe := composedGIFBytes(g) / int64(max(len(g.Image), 1)) * gifKeyframeCopies(g); perKeyframe > 0 {
+		if limit := keyframeBytes / perKeyframe; limit <= 0 {
+			s.keyframeEvery = len(g.Image) + 1
+		} else if need := (int64(len(g.Image)) + limit - 1) / limit; need > int64(s.keyframeEvery) {
+			s.keyframeEvery = int(need)
+		}
+	}
+	s.keyframes = make([]*gifKeyframe, len(g.Image)/s.keyframeEvery+1)
+	return s
+}
+
+// gifKeyframeCopies 是一个关键帧要保存几张画布：用到 DisposalPrevious 时还要保存恢复用的画布。
+func gifKeyframeCopies(g *gif.GIF) int64 {
+	for _, d := range g.Disposal {
+		if d == gif.DisposalPrevious {
+			return 2
+		}
+	}
+	return 1
+}
+
+func (s *gifStream) len() int {
+	return len(s.comp.g.Image)
+}
+
+func (s *gifStream) frame(i int) image.Image {
+	if i < 0 || i >= s.len() || len(s.ring) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slot := i % len(s.ring)
+	if s.ringIndex[slot] != i {
+		s.composeTo(i)
+	}
+	// 缓冲槽之后会被覆盖，交出去的帧可能正在显示，返回一份拷贝
+	frame := image.NewRGBA(s.ring[slot].Rect)
+	copy(frame.Pix, s.ring[slot].Pix)
+	return frame
+}
+
+// composeTo 合成到第 i 帧，沿途的帧都放进环形缓冲。
+func (s *gifStream) composeTo(i int) {
+	if i < s.comp.next {
+		s.seekKeyframe(i)
+	}
+	for s.comp.next <= i {
+		s.saveKeyframe()
+		s.comp.step()
+		n := s.comp.next - 1
+		k := n % len(s.ring)
+		if s.ring[k] == nil {
+			s.ring[k] = image.NewRGBA(s.comp.bounds)
+		}
+		copy(s.ring[k].Pix, s.comp.canvas.Pix)
+		s.ringIndex[k] = n
+	}
+}
+
+// saveKeyframe 在合成关键帧位置的那一帧之前保存合成状态。
+func (s *gifStream) saveKeyframe() {
+	next := s.comp.next
+	if next == 0 || next%s.keyframeEvery != 0 {
+		return
+	}
+	k := next / s.keyframeEvery
+	if k >= len(s.keyframes) || s.keyframes[k] != nil {
+		return
+	}
+	kf := &gifKeyframe{canvas: cloneRGBA(s.comp.canvas, s.comp.bounds)}
+	if s.comp.restore != nil {
+		kf.restore = cloneRGBA(s.comp.restore, s.comp.bounds)
+	}
+	s.keyframes[k] = kf
+}
+
+// seekKeyframe 回到第 i 帧之前最近的关键帧，没有时从头开始。
+func (s *gifStream) seekKeyframe(i int) {
+	for k := min(i/s.keyframeEvery, len(s.keyframes)-1); k > 0; k-- {
+		kf := s.keyframes[k]
+		if kf == nil {
+			continue
+		}
+		copy(s.comp.canvas.Pix, kf.canvas.Pix)
+		if kf.restore != nil {
+			if s.comp.restore == nil {
+				s.comp.restore = image.NewRGBA(s.comp.bounds)
+			}
+			copy(s.comp.restore.Pix, kf.restore.Pix)
+		}
+		s.comp.next = k * s.keyframeEvery
+		return
+	}
+	s.comp.reset()
+}
@@ -0,0 +1,184 @@
+package player
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func newTestStreamGIF(frameCount int) *gif.GIF {
+	palette := color.Palette{
+		color.RGBA{0, 0, 0, 0},
+		color.RGBA{255, 0, 0, 255},
+		color.RGBA{0, 0, 255, 255},
+		color.RGBA{0, 255, 0, 255},
+	}
+	g := &gif.GIF{Config: image.Config{Width: 4, Height: 1, ColorModel: palette}}
+	for i := 0; i < frameCount; i++ {
+		x := i % 4
+		frame := image.NewPaletted(image.Rect(x, 0, x+1, 1), palette)
+		frame.SetColorIndex(x, 0, uint8(1+i%3))
+		disposal := byte(gif.DisposalNone)
+		switch i % 3 {
+		case 1:
+			disposal = gif.DisposalBackground
+		case 2:
+			disposal = gif.DisposalPrevious
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 2)
+		g.Disposal = append(g.Disposal, disposal)
+	}
+	return g
+}
+
+func assertSameFrame(t *testing.T, i int, got, want image.Image) {
+	t.Helper()
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, a1 := got.At(x, y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				t.Fatalf("frame %d pixel (%d,%d) differs from pre-composed frame", i, x, y)
+			}
+		}
+	}
+}
+
+func TestGIFStream_MatchesPreComposedFrames(t *testing.T) {
+	t.Parallel()
+
+	g := newTestStreamGIF(10)
+	want := composeGIFFrames(g)
+	s := newGIFStream(g, 3, 1<<20)
+
+	if s.len() != len(want) {
+		t.Fatalf("stream len = %d, want %d", s.len(), len(want))
+	}
+	// 顺序播放两轮，第二轮需要从头重新合成
+	for loop := 0; loop < 2; loop++ {
+		for i := range want {
+			assertSameFrame(t, i, s.frame(i), want[i])
+		}
+	}
+	// 在环形缓冲内往回取，以及越界访问
+	assertSameFrame(t, 8, s.frame(8), want[8])
+	assertSameFrame(t, 2, s.frame(2), want[2])
+	if s.frame(-1) != nil || s.frame(10) != nil {
+		t.Fatalf("out-of-range frame should be nil")
+	}
+}
+
+func TestGIFStream_ReturnsOwnedFrames(t *testing.T) {
+	t.Parallel()
+
+	g := newTestStreamGIF(10)
+	want := composeGIFFrames(g)
+	s := newGIFStream(g, 3, 0)
+
+	// 交出去的帧可能还在显示，之后合成别的帧不能改动它
+	first := s.frame(0)
+	for i := range want {
+		s.frame(i)
+	}
+	assertSameFrame(t, 0, first, want[0])
+}
+
+func TestGIFStream_ReversePlayUsesKeyframes(t *testing.T) {
+	t.Parallel()
+
+	g := newTestStreamGIF(30)
+	want := composeGIFFrames(g)
+	s := newGIFStream(g, 3, 1<<20)
+	if s.keyframeEvery != 3 {
+		t.Fatalf("keyframe interval = %d, want the ring size", s.keyframeEvery)
+	}
+	for i := range want {
+		s.frame(i)
+	}
+	// 关键帧之前的帧都换成整张洋红色，如果往回时从第 0 帧重新合成，结果就会出错
+	original := append([]*image.Paletted(nil), g.Image...)
+	poison := image.NewPaletted(image.Rect(0, 0, 4, 1), color.Palette{color.RGBA{255, 0, 255, 255}})
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := range g.Image {
+			g.Image[j] = original[j]
+			if j < i/s.keyframeEvery*s.keyframeEvery {
+				g.Image[j] = poison
+			}
+		}
+		assertSameFrame(t, i, s.frame(i), want[i])
+	}
+	copy(g.Image, original)
+
+	// 关键帧受内存上限限制，放不下时间隔变大
+	tight := newGIFStream(g, 3, 4*4*2*2)
+	if tight.keyframeEvery != 15 {
+		t.Fatalf("tight keyframe interval = %d, want 15", tight.keyframeEvery)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		assertSameFrame(t, i, tight.frame(i), want[i])
+	}
+}
+
+func TestGIFStream_RingSizeBounds(t *testing.T) {
+	t.Parallel()
+
+	if got := len(newGIFStream(newTestStreamGIF(10), 1, 0).ring); got != minStreamingRingSize {
+		t.Fatalf("ring size = %d, want min %d", got, minStreamingRingSize)
+	}
+	if got := len(newGIFStream(newTestStreamGIF(2), 8, 0).ring); got != 2 {
+		t.Fatalf("ring size should not exceed frame count, got %d", got)
+	}
+}
+
+func TestShouldStreamGIF(t *testing.T) {
+	t.Parallel()
+
+	g := newTestStreamGIF(10) // 4x1x4 字节 x 10 帧 = 160 字节
+	if shouldStreamGIF(g, StreamingOptions{ThresholdBytes: 0}) {
+		t.Fatalf("non-positive threshold should disable streaming")
+	}
+	if shouldStreamGIF(g, StreamingOptions{ThresholdBytes: 160}) {
+		t.Fatalf("exactly at threshold should stay pre-composed")
+	}
+	if !shouldStreamGIF(g, StreamingOptions{ThresholdBytes: 159}) {
+		t.Fatalf("above threshold should stream")
+	}
+}
+
+func TestGIFDecoder_StreamsAboveThreshold(t *testing.T) {
+	old := currentStreamingOptions()
+	t.Cleanup(func() { SetStreamingOptions(old) })
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, newTestStreamGIF(6)); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+
+	SetStreamingOptions(StreamingOptions{ThresholdBytes: 1 << 20, RingSize: 1})
+	anim, err := gifDecoder{}.DecodeAnimation(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+	if anim.stream != nil || len(anim.Frames) != 6 {
+		t.Fatalf("small gif should be fully cached")
+	}
+
+	SetStreamingOptions(StreamingOptions{ThresholdBytes: 10, RingSize: 1})
+	if got := currentStreamingOptions().RingSize; got != minStreamingRingSize {
+		t.Fatalf("ring size should be clamped to %d, got %d", minStreamingRingSize, got)
+	}
+	anim, err = gifDecoder{}.DecodeAnimation(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("DecodeAnimation: %v", err)
+	}
+	if anim.stream == nil || len(anim.Frames) != 0 {
+		t.Fatalf("large gif should stream frames")
+	}
+	if anim.frameCount() != 6 || anim.frameAt(5) == nil {
+		t.Fatalf("streamed animation should expose all frames")
+	}
+}
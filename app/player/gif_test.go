@@ -148,3 +148,23 @@ func assertRGBA(t *testing.T, got color.Color, want color.RGBA) {
 		t.Fatalf("got RGBA %+v, want %+v", gotRGBA, want)
 	}
 }
+
+func TestGIFPlayCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		loopCount int
+		want      int
+	}{
+		{loopCount: -1, want: 1},
+		{loopCount: 0, want: 0},
+		{loopCount: 1, want: 2},
+		{loopCount: 4, want: 5},
+	}
+
+	for _, tc := range tests {
+		if got := gifPlayCount(tc.loopCount); got != tc.want {
+			t.Fatalf("gifPlayCount(%d) = %d, want %d", tc.loopCount, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package player
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"io"
+)
+
+type jpegDecoder struct{}
+
+func (jpegDecoder) Name() string { return "jpeg" }
+
+func (jpegDecoder) Extensions() []string { return []string{"jpeg", "jpg"} }
+
+func (jpegDecoder) Sniff(header []byte) bool {
+	return bytes.HasPrefix(header, []byte{0xff, 0xd8, 0xff})
+}
+
+// DecodeConfig 返回按 EXIF 方向摆正后的宽高。
+func (jpegDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	orientation, r := peekJPEGOrientation(r)
+	cfg, err := jpeg.DecodeConfig(r)
+	if err == nil && orientationSwapsSize(orientation) {
+		cfg.Width, cfg.Height = cfg.Height, cfg.Width
+	}
+	return cfg, err
+}
+
+// DecodeStill 解码后按 EXIF 方向旋转或翻转，手机拍的照片才不会躺着。
+func (jpegDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	orientation, r := peekJPEGOrientation(r)
+	img, err := jpeg.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return applyOrientation(img, orientation), nil
+}
+
+// peekJPEGOrientation 读出头部的 EXIF 方向，返回的 Reader 仍从文件开头读起。
+func peekJPEGOrientation(r io.Reader) (int, io.Reader) {
+	var head bytes.Buffer
+	orientation := readJPEGOrientation(io.TeeReader(r, &head))
+	return orientation, io.MultiReader(&head, r)
+}
+
+func (jpegDecoder) DecodeAnimation(io.Reader) (*Animation, error) {
+	return nil, nil
+}
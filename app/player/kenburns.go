@@ -0,0 +1,218 @@
+package player
+
+import (
+	"context"
+	"image"
+	"math/rand"
+	"time"
+
+	"fyne.io/fyne/v2"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	DefaultKenBurnsIntensity = 0.15
+	MinKenBurnsIntensity     = 0.05
+	MaxKenBurnsIntensity     = 0.5
+	DefaultKenBurnsCycle     = 20 * time.Second
+	MinKenBurnsCycle         = 5 * time.Second
+	MaxKenBurnsCycle         = 5 * time.Minute
+	// 缓慢的平移缩放不需要 60 帧
+	kenBurnsFrameInterval = time.Second / 30
+)
+
+// KenBurnsSettings 控制静态图的缓慢平移缩放。
+type KenBurnsSettings struct {
+	Enabled bool
+	// Intensity 是最大放大比例，0.15 表示最多只显示原图 85% 的区域
+	Intensity float64
+	// Cycle 是从一个取景框移动到下一个取景框的时长
+	Cycle time.Duration
+}
+
+func normalizeKenBurns(s KenBurnsSettings) KenBurnsSettings {
+	if s.Intensity <= 0 {
+		s.Intensity = DefaultKenBurnsIntensity
+	}
+	s.Intensity = min(max(s.Intensity, MinKenBurnsIntensity), MaxKenBurnsIntensity)
+	if s.Cycle <= 0 {
+		s.Cycle = DefaultKenBurnsCycle
+	}
+	s.Cycle = min(max(s.Cycle, MinKenBurnsCycle), MaxKenBurnsCycle)
+	return s
+}
+
+// SetKenBurns 修改静态图的平移缩放效果，下一张静态图开始生效。
+func (p *Player) SetKenBurns(s KenBurnsSettings) {
+	s = normalizeKenBurns(s)
+	p.settingsMu.Lock()
+	p.kenBurns = s
+	p.settingsMu.Unlock()
+}
+
+func (p *Player) KenBurns() KenBurnsSettings {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	return normalizeKenBurns(p.kenBurns)
+}
+
+// kenBurns 是一张静态图的平移缩放状态，取景框用相对原图的比例表示。
+type kenBurns struct {
+	settings KenBurnsSettings
+	src      *image.RGBA
+	rnd      func() float64
+	from, to rectF
+
+	// 两块画面缓冲轮流使用，一块在显示时往另一块画下一帧
+	buffers [2]*image.RGBA
+	next    int
+}
+
+type rectF struct {
+	x, y, w, h float64
+}
+
+func newKenBurns(src image.Image, renderSize image.Point, s KenBurnsSettings, filters FilterSettings, rnd func() float64) *kenBurns {
+	if rnd == nil {
+		rnd = rand.Float64
+	}
+	source := kenBurnsSource(src, renderSize, s.Intensity)
+	if !filters.IsIdentity() {
+		// 模糊半径按屏幕像素设置，换算成原图像素
+		if renderSize.X > 0 && renderSize.Y > 0 {
+			fit := fitSize(source.Bounds().Size(), renderSize)
+			filters.Blur *= float64(source.Bounds().Dx()) / float64(fit.X)
+		}
+		source = applyFilters(source, filters).(*image.RGBA)
+	}
+	k := &kenBurns{settings: s, src: source, rnd: rnd}
+	k.from = k.randomRect()
+	k.to = k.randomRect()
+	return k
+}
+
+// kenBurnsSource 把原图转成 RGBA 方便快速采样；矢量图按放大后的屏幕尺寸栅格化，放大时也清晰。
+func kenBurnsSource(src image.Image, renderSize image.Point, intensity float64) *image.RGBA {
+	if s, ok := src.(ScalableImage); ok && renderSize.X > 0 && renderSize.Y > 0 {
+		box := image.Pt(int(float64(renderSize.X)/(1-intensity)), int(float64(renderSize.Y)/(1-intensity)))
+		src = s.RenderAt(fitSize(src.Bounds().Size(), box))
+	}
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	return copyToRGBA(src)
+}
+
+func (k *kenBurns) randomRect() rectF {
+	scale := 1 - k.settings.Intensity*k.rnd()
+	return rectF{
+		x: (1 - scale) * k.rnd(),
+		y: (1 - scale) * k.rnd(),
+		w: scale,
+		h: scale,
+	}
+}
+
+// advance 进入下一个周期：从上一个终点出发，随机一个新终点。
+func (k *kenBurns) advance() {
+	k.from = k.to
+	k.to = k.randomRect()
+}
+
+func (k *kenBurns) rectAt(progress float64) rectF {
+	t := easeInOut(progress)
+	return rectF{
+		x: k.from.x + (k.to.x-k.from.x)*t,
+		y: k.from.y + (k.to.y-k.from.y)*t,
+		w: k.from.w + (k.to.w-k.from.w)*t,
+		h: k.from.h + (k.to.h-k.from.h)*t,
+	}
+}
+
+// frame 把取景框内的内容缩放到 renderSize 并应用遮罩。两块缓冲轮流使用，
+// 调用方要等上一帧换上屏幕之后再取下一帧，那时再上一帧的缓冲已经不在显示了。
+func (k *kenBurns) frame(progress float64, renderSize image.Point, mask *maskState) image.Image {
+	b := k.src.Bounds()
+	r := k.rectAt(progress)
+	crop := image.Rect(
+		int(r.x*float64(b.Dx())),
+		int(r.y*float64(b.Dy())),
+		int((r.x+r.w)*float64(b.Dx())),
+		int((r.y+r.h)*float64(b.Dy())),
+	).Intersect(b)
+	if crop.Empty() {
+		crop = b
+	}
+
+	size := b.Size()
+	if renderSize.X > 0 && renderSize.Y > 0 {
+		size = fitSize(b.Size(), renderSize)
+	}
+	buf := k.buffers[k.next]
+	if buf == nil || buf.Bounds().Size() != size {
+		buf = image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+		k.buffers[k.next] = buf
+	}
+	k.next = 1 - k.next
+	xdraw.ApproxBiLinear.Scale(buf, buf.Bounds(), k.src, crop, xdraw.Src, nil)
+	if mask != nil {
+		maskInPlace(buf, mask.alpha(size))
+	}
+	return buf
+}
+
+func (p *Player) currentRenderSize() image.Point {
+	p.renderMu.Lock()
+	defer p.renderMu.Unlock()
+	return p.renderSize
+}
+
+func (p *Player) clearCurrentFrame() {
+	p.renderMu.Lock()
+	p.currentFrame = shownFrame{}
+	p.renderMu.Unlock()
+}
+
+// runKenBurns 持续播放平移缩放，暂停原因和动图一致：拖拽、全透明、用户暂停时都不渲染。
+func (p *Player) runKenBurns(ctx context.Context, playbackID uint64, k *kenBurns) {
+	start := time.Now()
+	for {
+		if !p.isPlaybackActive(playbackID) {
+			return
+		}
+		if p.RenderPaused() {
+			pausedAt := time.Now()
+			if !p.waitRenderResumed(playbackID) {
+				return
+			}
+			start = start.Add(time.Since(pausedAt))
+		}
+
+		elapsed := time.Since(start)
+		for elapsed >= k.settings.Cycle {
+			k.advance()
+			start = start.Add(k.settings.Cycle)
+			elapsed -= k.settings.Cycle
+		}
+
+		frame := k.frame(float64(elapsed)/float64(k.settings.Cycle), p.currentRenderSize(), p.currentMask())
+		shown := make(chan struct{})
+		fyne.Do(func() {
+			defer close(shown)
+			if !p.isPlaybackActive(playbackID) {
+				return
+			}
+			p.Canvas.Image = frame
+			p.Canvas.Refresh()
+		})
+		// 下一帧要画进上一帧的缓冲，等这一帧换上屏幕后才能画
+		select {
+		case <-shown:
+		case <-ctx.Done():
+			return
+		}
+		if !sleepContext(ctx, kenBurnsFrameInterval) {
+			return
+		}
+	}
+}
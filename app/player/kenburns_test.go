@@ -0,0 +1,178 @@
+package player
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestNormalizeKenBurns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   KenBurnsSettings
+		want KenBurnsSettings
+	}{
+		{"defaults", KenBurnsSettings{Enabled: true}, KenBurnsSettings{Enabled: true, Intensity: DefaultKenBurnsIntensity, Cycle: DefaultKenBurnsCycle}},
+		{"too weak", KenBurnsSettings{Intensity: 0.01, Cycle: time.Second}, KenBurnsSettings{Intensity: MinKenBurnsIntensity, Cycle: MinKenBurnsCycle}},
+		{"too strong", KenBurnsSettings{Intensity: 2, Cycle: time.Hour}, KenBurnsSettings{Intensity: MaxKenBurnsIntensity, Cycle: MaxKenBurnsCycle}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := normalizeKenBurns(tt.in); got != tt.want {
+				t.Fatalf("normalizeKenBurns(%+v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKenBurns_RectsStayInsideImage(t *testing.T) {
+	t.Parallel()
+
+	values := []float64{0, 1, 0.5, 0.999, 0.25, 0.75}
+	i := 0
+	rnd := func() float64 {
+		v := values[i%len(values)]
+		i++
+		return v
+	}
+	s := normalizeKenBurns(KenBurnsSettings{Enabled: true, Intensity: 0.3})
+	k := newKenBurns(solidRGBA(40, 20, color.RGBA{255, 0, 0, 255}), image.Point{}, s, FilterSettings{}, rnd)
+
+	for n := 0; n < 10; n++ {
+		for _, progress := range []float64{0, 0.3, 1} {
+			r := k.rectAt(progress)
+			if r.w < 1-s.Intensity-1e-9 || r.w > 1 || r.x < 0 || r.y < 0 || r.x+r.w > 1+1e-9 || r.y+r.h > 1+1e-9 {
+				t.Fatalf("rect %+v escapes the image", r)
+			}
+		}
+		prevEnd := k.rectAt(1)
+		k.advance()
+		if k.rectAt(0) != prevEnd {
+			t.Fatalf("next cycle should start where the previous one ended")
+		}
+	}
+}
+
+func TestKenBurns_FramesAlternateTwoBuffers(t *testing.T) {
+	t.Parallel()
+
+	s := normalizeKenBurns(KenBurnsSettings{Enabled: true})
+	k := newKenBurns(solidRGBA(200, 100, color.RGBA{0, 0, 255, 255}), image.Point{}, s, FilterSettings{}, nil)
+
+	a := k.frame(0, image.Pt(50, 50), nil)
+	b := k.frame(0.5, image.Pt(50, 50), nil)
+	if a.Bounds().Size() != image.Pt(50, 25) {
+		t.Fatalf("frame size = %v, want 50x25", a.Bounds().Size())
+	}
+	if a == b {
+		t.Fatalf("consecutive frames should use different buffers")
+	}
+	if c := k.frame(1, image.Pt(50, 50), nil); c != a {
+		t.Fatalf("the third frame should reuse the first buffer")
+	}
+	assertRGBA(t, b.At(25, 12), color.RGBA{0, 0, 255, 255})
+	if d := k.frame(1, image.Pt(80, 80), nil); d == b || d.Bounds().Size() != image.Pt(80, 40) {
+		t.Fatalf("buffer should be reallocated when the render size changes")
+	}
+}
+
+// gradientRGBA 横向渐变，取景框稍有移动画面就会不同
+func gradientRGBA(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 255 / w), 0, uint8(y * 255 / h), 255})
+		}
+	}
+	return img
+}
+
+// startKenBurns 用很短的周期直接跑平移缩放，取景框在整张图和左上角四分之一之间来回移动。
+func startKenBurns(p *Player, src image.Image) uint64 {
+	values := []float64{0, 0, 0, 1, 0, 0}
+	i := 0
+	rnd := func() float64 {
+		v := values[i%len(values)]
+		i++
+		return v
+	}
+	s := KenBurnsSettings{Enabled: true, Intensity: 0.5, Cycle: 40 * time.Millisecond}
+	k := newKenBurns(src, image.Point{}, s, FilterSettings{}, rnd)
+	id := p.beginPlayback()
+	p.goPlayback(id, func(ctx context.Context) {
+		p.runKenBurns(ctx, id, k)
+	})
+	return id
+}
+
+func TestRunKenBurns_KeepsRenderingUntilPaused(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	src := gradientRGBA(100, 100)
+	p := NewPlayer(a, w)
+	p.setRenderSize(image.Pt(100, 100))
+
+	// 播放 goroutine 在写画布，停掉播放（会等它退出）之后再读
+	startKenBurns(p, src)
+	time.Sleep(200 * time.Millisecond)
+	p.beginPlayback()
+	frame, ok := p.Canvas.Image.(*image.RGBA)
+	if !ok {
+		t.Fatalf("ken burns should have shown a frame, got %T", p.Canvas.Image)
+	}
+	if string(frame.Pix) == string(src.Pix) {
+		t.Fatalf("ken burns should keep moving past the first frame")
+	}
+
+	// 暂停期间一帧都不画，恢复后继续
+	p.Canvas.Image = nil
+	p.SetRenderPaused(true)
+	startKenBurns(p, src)
+	time.Sleep(100 * time.Millisecond)
+	p.beginPlayback()
+	if p.Canvas.Image != nil {
+		t.Fatalf("ken burns should not render while paused")
+	}
+
+	startKenBurns(p, src)
+	time.Sleep(50 * time.Millisecond)
+	p.SetRenderPaused(false)
+	time.Sleep(100 * time.Millisecond)
+	p.Close()
+	if p.Canvas.Image == nil {
+		t.Fatalf("ken burns should resume after the pause ends")
+	}
+	if n := p.running.Load(); n != 0 {
+		t.Fatalf("running goroutines after Close = %d", n)
+	}
+}
+
+func TestShowStillImage_KenBurnsFitsRenderSize(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	p := NewPlayer(a, w)
+	p.SetKenBurns(KenBurnsSettings{Enabled: true})
+	id := p.beginPlayback()
+	showStillImage(p, solidRGBA(100, 50, color.RGBA{255, 0, 0, 255}), id)
+	time.Sleep(100 * time.Millisecond)
+	p.Close()
+
+	size := p.currentRenderSize()
+	if p.Canvas.Image == nil || p.Canvas.Image.Bounds().Size() != fitSize(image.Pt(100, 50), size) {
+		t.Fatalf("ken burns frame should fit render size %v", size)
+	}
+}
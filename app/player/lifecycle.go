@@ -0,0 +1,108 @@
+package player
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// playback 是一次播放的生命周期。换图或 Close 时取消 ctx，
+// 正在等待的 goroutine 会立即醒来退出，wg 用来等它们全部结束。
+type playback struct {
+	id     uint64
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// beginPlayback 取消上一次播放并等它的 goroutine 全部退出，再开始新的播放。
+func (p *Player) beginPlayback() uint64 {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p.lifeMu.Lock()
+	prev := p.playback
+	if prev != nil {
+		prev.cancel()
+	}
+	id := p.playbackID.Add(1)
+	if p.closed {
+		cancel()
+	}
+	p.playback = &playback{id: id, ctx: ctx, cancel: cancel}
+	p.lifeMu.Unlock()
+
+	if prev != nil {
+		prev.wg.Wait()
+	}
+	return id
+}
+
+func (p *Player) isPlaybackActive(id uint64) bool {
+	return p.playbackID.Load() == id
+}
+
+// playbackContext 返回某次播放的 ctx，已经过期的播放返回已取消的 ctx。
+func (p *Player) playbackContext(id uint64) context.Context {
+	p.lifeMu.Lock()
+	defer p.lifeMu.Unlock()
+	if p.playback != nil && p.playback.id == id {
+		return p.playback.ctx
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+// goPlayback 为某次播放启动 goroutine，播放已过期或已取消时不启动，返回 false。
+func (p *Player) goPlayback(id uint64, fn func(ctx context.Context)) bool {
+	p.lifeMu.Lock()
+	pb := p.playback
+	if pb == nil || pb.id != id || pb.ctx.Err() != nil {
+		p.lifeMu.Unlock()
+		return false
+	}
+	pb.wg.Add(1)
+	p.running.Add(1)
+	p.lifeMu.Unlock()
+
+	go func() {
+		defer pb.wg.Done()
+		defer p.running.Add(-1)
+		fn(pb.ctx)
+	}()
+	return true
+}
+
+// Close 停止播放并等待所有播放 goroutine 退出，之后 Play 不再生效。
+func (p *Player) Close() {
+	p.lifeMu.Lock()
+	p.closed = true
+	pb := p.playback
+	if pb != nil {
+		pb.cancel()
+	}
+	p.playbackID.Add(1)
+	p.lifeMu.Unlock()
+
+	if pb != nil {
+		pb.wg.Wait()
+	}
+}
+
+func (p *Player) isClosed() bool {
+	p.lifeMu.Lock()
+	defer p.lifeMu.Unlock()
+	return p.closed
+}
+
+// sleepContext 睡眠 d，ctx 取消时提前返回 false。
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,135 @@
+package player
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+// 每帧停留很久，旧的播放只能靠取消才能及时退出
+func writeSlowTestGIF(t *testing.T) string {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 2, 2), palette)
+		frame.SetColorIndex(0, 0, uint8(i))
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 1000) // 10 秒
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+	return writeTestFile(t, "slow.gif", buf.Bytes())
+}
+
+func TestPlay_RapidSwitchDoesNotLeakGoroutines(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	path := writeSlowTestGIF(t)
+	p := NewPlayer(a, w)
+	for i := 0; i < 50; i++ {
+		p.Play(path)
+		if n := p.running.Load(); n > 1 {
+			t.Fatalf("after play #%d there are %d playback goroutines, want at most 1", i+1, n)
+		}
+	}
+
+	p.Pause()
+	for i := 0; i < 10; i++ {
+		p.Play(path)
+		p.Pause()
+	}
+	if n := p.running.Load(); n > 1 {
+		t.Fatalf("paused playbacks piled up: %d goroutines", n)
+	}
+
+	start := time.Now()
+	p.Close()
+	if n := p.running.Load(); n != 0 {
+		t.Fatalf("Close left %d playback goroutines running", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Close took %v, sleeping goroutines should be woken immediately", elapsed)
+	}
+}
+
+func TestPlay_WaitsForPreviousPlayback(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	path := writeSlowTestGIF(t)
+	p := NewPlayer(a, w)
+	// 模拟被取消后还要一会儿才退出的旧播放
+	release := make(chan struct{})
+	exited := make(chan struct{})
+	old := p.beginPlayback()
+	p.goPlayback(old, func(context.Context) {
+		<-release
+		close(exited)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.Play(path)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("Play returned while the previous playback was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	select {
+	case <-exited:
+	default:
+		t.Fatalf("previous playback should have exited before Play returned")
+	}
+	p.Close()
+}
+
+func TestClose_StopsFurtherPlayback(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	path := writeSlowTestGIF(t)
+	p := NewPlayer(a, w)
+	p.Close()
+	p.Play(path)
+	if n := p.running.Load(); n != 0 {
+		t.Fatalf("Play after Close started %d goroutines", n)
+	}
+	if p.CurrentPath() != "" {
+		t.Fatalf("Play after Close should be ignored")
+	}
+}
+
+func TestGoPlayback_RejectsStalePlayback(t *testing.T) {
+	t.Parallel()
+
+	p := &Player{}
+	old := p.beginPlayback()
+	p.beginPlayback()
+	if p.goPlayback(old, func(ctx context.Context) {}) {
+		t.Fatalf("goPlayback should not start goroutines for a superseded playback")
+	}
+	if err := p.playbackContext(old).Err(); err == nil {
+		t.Fatalf("context of a superseded playback should be cancelled")
+	}
+}
@@ -0,0 +1,140 @@
+package player
+
+// LoopMode 决定动图播放几遍、怎么循环。
+type LoopMode int
+
+const (
+	// LoopAuthored 按文件里写的循环次数播放
+	LoopAuthored LoopMode = iota
+	// LoopForever 无限循环
+	LoopForever
+	// LoopTimes 播放 Count 遍
+	LoopTimes
+	// LoopPingPong 正放再倒放，Count 是往返次数，0 表示无限往返
+	LoopPingPong
+)
+
+// LoopEnd 决定有限次数的动图播完后停在哪一帧。
+type LoopEnd int
+
+const (
+	LoopEndLast LoopEnd = iota
+	LoopEndFirst
+)
+
+// LoopSettings 是动图的循环方式，零值表示按文件设置播放、播完停在最后一帧。
+type LoopSettings struct {
+	Mode  LoopMode
+	Count int
+	End   LoopEnd
+}
+
+func (p *Player) SetLoopSettings(s LoopSettings) {
+	p.settingsMu.Lock()
+	p.loop = s
+	p.settingsMu.Unlock()
+}
+
+func (p *Player) LoopSettings() LoopSettings {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	return p.loop
+}
+
+// frameCursor 按循环设置依次给出要显示的帧序号。
+type frameCursor struct {
+	frames   int
+	passes   int // 总遍数，0 表示无限；往返模式下正放、倒放各算一遍
+	pingPong bool
+	endFirst bool
+
+	pass int
+	pos  int
+	done bool
+}
+
+func newFrameCursor(frames, authoredLoops int, s LoopSettings) *frameCursor {
+	c := &frameCursor{frames: frames, endFirst: s.End == LoopEndFirst}
+	switch s.Mode {
+	case LoopForever:
+		c.passes = 0
+	case LoopTimes:
+		c.passes = max(s.Count, 1)
+	case LoopPingPong:
+		c.pingPong = frames > 1
+		c.passes = max(s.Count, 0) * 2
+	default:
+		c.passes = max(authoredLoops, 0)
+	}
+	return c
+}
+
+// next 返回下一帧的序号；last 表示这是最后要显示的一帧，ok 为 false 表示播放结束。
+func (c *frameCursor) next() (index int, last bool, ok bool) {
+	if c.done || c.frames <= 0 {
+		return 0, false, false
+	}
+
+	if c.pos >= c.passLen() {
+		c.pass++
+		c.pos = 0
+		if c.passes > 0 && c.pass >= c.passes {
+			c.done = true
+			if c.endFirst && c.lastIndex() != 0 {
+				return 0, true, true
+			}
+			return 0, false, false
+		}
+	}
+
+	index = c.indexAt(c.pass, c.pos)
+	c.pos++
+	last = c.passes > 0 && c.pass == c.passes-1 && c.pos == c.passLen() && (!c.endFirst || index == 0)
+	if last {
+		c.done = true
+	}
+	return index, last, true
+}
+
+// seek 让下一次 next 从 index 之后的帧继续，用于单帧步进后恢复播放。
+func (c *frameCursor) seek(index int) {
+	if c.done || index < 0 || index >= c.frames {
+		return
+	}
+	for pos := 0; pos < c.passLen(); pos++ {
+		if c.indexAt(c.pass, pos) == index {
+			c.pos = pos + 1
+			return
+		}
+	}
+	// 往返模式下这一遍不含起点帧，从这一遍开头继续即可
+	c.pos = 0
+}
+
+// 往返模式下除第一遍外，每遍都跳过和上一遍重复的端点帧
+func (c *frameCursor) passLen() int {
+	if c.pingPong && c.pass > 0 {
+		return c.frames - 1
+	}
+	return c.frames
+}
+
+func (c *frameCursor) indexAt(pass, pos int) int {
+	if !c.pingPong {
+		return pos
+	}
+	if pass == 0 {
+		return pos
+	}
+	if pass%2 == 1 {
+		return c.frames - 2 - pos
+	}
+	return pos + 1
+}
+
+func (c *frameCursor) lastIndex() int {
+	if c.pingPong && c.passes%2 == 0 {
+		return 0
+	}
+	return c.frames - 1
+}
@@ -0,0 +1,134 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+)
+
+func collectFrames(c *frameCursor, limit int) ([]int, int) {
+	var out []int
+	lastAt := -1
+	for len(out) < limit {
+		i, last, ok := c.next()
+		if !ok {
+			break
+		}
+		if last {
+			lastAt = len(out)
+		}
+		out = append(out, i)
+	}
+	return out, lastAt
+}
+
+func TestFrameCursor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		frames   int
+		authored int
+		settings LoopSettings
+		want     []int
+	}{
+		{
+			name:     "authored once",
+			frames:   3,
+			authored: 1,
+			want:     []int{0, 1, 2},
+		},
+		{
+			name:     "authored twice end on first",
+			frames:   3,
+			authored: 2,
+			settings: LoopSettings{End: LoopEndFirst},
+			want:     []int{0, 1, 2, 0, 1, 2, 0},
+		},
+		{
+			name:     "times overrides authored",
+			frames:   2,
+			authored: 0,
+			settings: LoopSettings{Mode: LoopTimes, Count: 3},
+			want:     []int{0, 1, 0, 1, 0, 1},
+		},
+		{
+			name:     "times with invalid count plays once",
+			frames:   2,
+			settings: LoopSettings{Mode: LoopTimes},
+			want:     []int{0, 1},
+		},
+		{
+			name:     "ping pong round trip",
+			frames:   4,
+			settings: LoopSettings{Mode: LoopPingPong, Count: 2},
+			want:     []int{0, 1, 2, 3, 2, 1, 0, 1, 2, 3, 2, 1, 0},
+		},
+		{
+			name:     "ping pong ends on first already",
+			frames:   3,
+			settings: LoopSettings{Mode: LoopPingPong, Count: 1, End: LoopEndFirst},
+			want:     []int{0, 1, 2, 1, 0},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, lastAt := collectFrames(newFrameCursor(tc.frames, tc.authored, tc.settings), 100)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("frames = %v, want %v", got, tc.want)
+			}
+			if lastAt != len(tc.want)-1 {
+				t.Fatalf("last flag at %d, want %d", lastAt, len(tc.want)-1)
+			}
+		})
+	}
+}
+
+func TestFrameCursor_Forever(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		authored int
+		settings LoopSettings
+		want     []int
+	}{
+		{name: "authored forever", authored: 0, want: []int{0, 1, 2, 0, 1, 2, 0}},
+		{name: "forever overrides authored", authored: 1, settings: LoopSettings{Mode: LoopForever}, want: []int{0, 1, 2, 0, 1, 2, 0}},
+		{name: "ping pong forever", settings: LoopSettings{Mode: LoopPingPong}, want: []int{0, 1, 2, 1, 0, 1, 2}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, lastAt := collectFrames(newFrameCursor(3, tc.authored, tc.settings), len(tc.want))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("frames = %v, want %v", got, tc.want)
+			}
+			if lastAt != -1 {
+				t.Fatalf("endless playback should never report a last frame")
+			}
+		})
+	}
+}
+
+func TestFrameCursor_Seek(t *testing.T) {
+	t.Parallel()
+
+	c := newFrameCursor(4, 0, LoopSettings{})
+	c.next()
+	c.seek(2)
+	if got, _ := collectFrames(c, 3); !reflect.DeepEqual(got, []int{3, 0, 1}) {
+		t.Fatalf("frames after seek = %v, want [3 0 1]", got)
+	}
+
+	pp := newFrameCursor(3, 0, LoopSettings{Mode: LoopPingPong})
+	collectFrames(pp, 4) // 0 1 2 1
+	pp.seek(2)
+	if got, _ := collectFrames(pp, 3); !reflect.DeepEqual(got, []int{1, 0, 1}) {
+		t.Fatalf("ping pong frames after seek = %v, want [1 0 1]", got)
+	}
+}
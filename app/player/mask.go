@@ -0,0 +1,248 @@
+package player
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// MaskKind 是画面的形状，形状外的像素变透明。
+type MaskKind int
+
+const (
+	MaskNone MaskKind = iota
+	MaskRoundedRect
+	MaskCircle
+	MaskEllipse
+	// MaskImage 用一张 PNG 的 alpha 通道做遮罩，拉伸到画面大小
+	MaskImage
+)
+
+const (
+	MaxMaskRadius = 0.5
+	// 自定义遮罩按这个网格记录不透明的位置，用来估算鼠标到形状的距离
+	maskSampleGrid = 64
+)
+
+// MaskSettings 是画面遮罩。Radius 是圆角半径占短边的比例，取值 0~0.5。
+type MaskSettings struct {
+	Kind   MaskKind
+	Radius float64
+	Path   string
+}
+
+// maskState 是生效中的遮罩，自定义遮罩图在设置时就加载好，只缓存当前尺寸的 alpha。
+type maskState struct {
+	settings MaskSettings
+	img      image.Image
+	// 自定义遮罩里不透明格子的中心，坐标是 0~1 的比例
+	opaque [][2]float64
+
+	mu        sync.Mutex
+	alphaSize image.Point
+	alphaImg  *image.Alpha
+}
+
+// SetMask 修改画面遮罩，当前画面立即按新遮罩重新渲染。自定义遮罩图加载失败时返回错误，遮罩不变。
+func (p *Player) SetMask(s MaskSettings) error {
+	s.Radius = clampFloat(s.Radius, 0, MaxMaskRadius)
+	if s.Kind != MaskImage {
+		s.Path = ""
+	}
+
+	var next *maskState
+	if s.Kind != MaskNone {
+		next = &maskState{settings: s}
+		if s.Kind == MaskImage {
+			img, err := loadMaskImage(s.Path)
+			if err != nil {
+				return err
+			}
+			next.img = img
+			next.opaque = opaqueSamples(img)
+		}
+	}
+
+	p.updateRender(func() bool {
+		if p.renderMask == nil && next == nil {
+			return false
+		}
+		if p.renderMask != nil && next != nil && p.renderMask.settings == s && s.Kind != MaskImage {
+			return false
+		}
+		p.renderMask = next
+		return true
+	})
+	return nil
+}
+
+func (p *Player) Mask() MaskSettings {
+	p.renderMu.Lock()
+	defer p.renderMu.Unlock()
+	if p.renderMask == nil {
+		return MaskSettings{}
+	}
+	return p.renderMask.settings
+}
+
+func (p *Player) currentMask() *maskState {
+	p.renderMu.Lock()
+	defer p.renderMu.Unlock()
+	return p.renderMask
+}
+
+func loadMaskImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode mask %s: %w", path, err)
+	}
+	if b := img.Bounds(); b.Dx() <= 0 || b.Dy() <= 0 {
+		return nil, fmt.Errorf("mask %s is empty", path)
+	}
+	return img, nil
+}
+
+func opaqueSamples(img image.Image) [][2]float64 {
+	b := img.Bounds()
+	var out [][2]float64
+	for gy := 0; gy < maskSampleGrid; gy++ {
+		for gx := 0; gx < maskSampleGrid; gx++ {
+			u := (float64(gx) + 0.5) / maskSampleGrid
+			v := (float64(gy) + 0.5) / maskSampleGrid
+			if maskImageAlpha(img, b, u, v) >= 0.5 {
+				out = append(out, [2]float64{u, v})
+			}
+		}
+	}
+	return out
+}
+
+func maskImageAlpha(img image.Image, b image.Rectangle, u, v float64) float64 {
+	x := b.Min.X + min(int(u*float64(b.Dx())), b.Dx()-1)
+	y := b.Min.Y + min(int(v*float64(b.Dy())), b.Dy()-1)
+	_, _, _, a := img.At(x, y).RGBA()
+	return float64(a) / 0xffff
+}
+
+// alpha 返回 size 尺寸下每个像素的覆盖率。
+func (m *maskState) alpha(size image.Point) *image.Alpha {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.alphaImg != nil && m.alphaSize == size {
+		return m.alphaImg
+	}
+
+	a := image.NewAlpha(image.Rect(0, 0, size.X, size.Y))
+	if m.settings.Kind == MaskImage {
+		xdraw.ApproxBiLinear.Scale(a, a.Bounds(), m.img, m.img.Bounds(), xdraw.Src, nil)
+	} else {
+		w, h := float64(size.X), float64(size.Y)
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				d := shapeDistance(m.settings, float64(x)+0.5, float64(y)+0.5, w, h)
+				// 边缘一个像素内做抗锯齿
+				a.Pix[y*a.Stride+x] = uint8(math.Round(clampFloat(0.5-d, 0, 1) * 255))
+			}
+		}
+	}
+	// 窗口缩放时尺寸一直在变，旧尺寸的不再保留
+	m.alphaSize, m.alphaImg = size, a
+	return a
+}
+
+// shapeDistance 返回点 (x, y) 到 w x h 区域内形状边缘的有向距离，形状内为负。
+func shapeDistance(s MaskSettings, x, y, w, h float64) float64 {
+	cx, cy := w/2, h/2
+	px, py := x-cx, y-cy
+	switch s.Kind {
+	case MaskRoundedRect:
+		r := s.Radius * math.Min(w, h)
+		qx := math.Abs(px) - (cx - r)
+		qy := math.Abs(py) - (cy - r)
+		outside := math.Hypot(math.Max(qx, 0), math.Max(qy, 0))
+		return outside + math.Min(math.Max(qx, qy), 0) - r
+	case MaskCircle:
+		return math.Hypot(px, py) - math.Min(w, h)/2
+	case MaskEllipse:
+		if cx <= 0 || cy <= 0 {
+			return 0
+		}
+		k := math.Hypot(px/cx, py/cy)
+		if k == 0 {
+			return -math.Min(cx, cy)
+		}
+		// 按半径比例缩放的近似距离，靠近边缘时足够准确
+		return (k - 1) * math.Hypot(px, py) / k
+	default:
+		return rectDistance(x, y, w, h)
+	}
+}
+
+func rectDistance(x, y, w, h float64) float64 {
+	dx := math.Max(math.Max(-x, x-w), 0)
+	dy := math.Max(math.Max(-y, y-h), 0)
+	return math.Hypot(dx, dy)
+}
+
+// DistanceToContent 返回窗口内坐标 (x, y) 到遮罩后画面的距离，在画面内为 0。
+// 没有遮罩时 ok 为 false，调用方按窗口矩形计算。
+func (p *Player) DistanceToContent(x, y, w, h float32) (distance float32, ok bool) {
+	m := p.currentMask()
+	if m == nil || w <= 0 || h <= 0 {
+		return 0, false
+	}
+	return float32(m.distance(float64(x), float64(y), float64(w), float64(h))), true
+}
+
+func (m *maskState) distance(x, y, w, h float64) float64 {
+	if m.settings.Kind != MaskImage {
+		return math.Max(shapeDistance(m.settings, x, y, w, h), 0)
+	}
+	if x >= 0 && y >= 0 && x < w && y < h && maskImageAlpha(m.img, m.img.Bounds(), x/w, y/h) >= 0.5 {
+		return 0
+	}
+	if len(m.opaque) == 0 {
+		return rectDistance(x, y, w, h)
+	}
+	best := math.Inf(1)
+	for _, pt := range m.opaque {
+		best = math.Min(best, math.Hypot(x-pt[0]*w, y-pt[1]*h))
+	}
+	return best
+}
+
+// maskInPlace 把 img 的每个像素乘上遮罩的覆盖率。
+func maskInPlace(img *image.RGBA, a *image.Alpha) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+w*4]
+		mask := a.Pix[y*a.Stride : y*a.Stride+w]
+		for x, m := range mask {
+			if m == 255 {
+				continue
+			}
+			for c := 0; c < 4; c++ {
+				row[x*4+c] = uint8(uint32(row[x*4+c]) * uint32(m) / 255)
+			}
+		}
+	}
+}
+
+// applyMask 返回遮罩后的新图，不修改 src。
+func applyMask(src image.Image, m *maskState) image.Image {
+	if m == nil || src == nil {
+		return src
+	}
+	dst := copyToRGBA(src)
+	maskInPlace(dst, m.alpha(dst.Bounds().Size()))
+	return dst
+}
@@ -0,0 +1,129 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestShapeDistance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    MaskSettings
+		x, y float64
+		want float64
+	}{
+		{"rect inside", MaskSettings{}, 10, 10, 0},
+		{"rect outside corner", MaskSettings{}, -3, -4, 5},
+		{"circle center", MaskSettings{Kind: MaskCircle}, 50, 25, -25},
+		{"circle outside corner", MaskSettings{Kind: MaskCircle}, 100, 25, 25},
+		{"ellipse on edge", MaskSettings{Kind: MaskEllipse}, 100, 25, 0},
+		{"rounded edge middle", MaskSettings{Kind: MaskRoundedRect, Radius: 0.2}, 50, 0, 0},
+		{"rounded corner", MaskSettings{Kind: MaskRoundedRect, Radius: 0.2}, 0, 0, math.Sqrt2*10 - 10},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := shapeDistance(tt.s, tt.x, tt.y, 100, 50); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("shapeDistance = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMask_CircleClearsCorners(t *testing.T) {
+	t.Parallel()
+
+	src := solidRGBA(20, 20, color.RGBA{255, 0, 0, 255})
+	out := applyMask(src, &maskState{settings: MaskSettings{Kind: MaskCircle}})
+	assertRGBA(t, out.At(0, 0), color.RGBA{})
+	assertRGBA(t, out.At(10, 10), color.RGBA{255, 0, 0, 255})
+	assertRGBA(t, src.At(0, 0), color.RGBA{255, 0, 0, 255})
+}
+
+func TestMaskAlpha_KeepsOnlyCurrentSize(t *testing.T) {
+	t.Parallel()
+
+	m := &maskState{settings: MaskSettings{Kind: MaskCircle}}
+	first := m.alpha(image.Pt(20, 20))
+	if m.alpha(image.Pt(20, 20)) != first {
+		t.Fatalf("same size should reuse the cached alpha")
+	}
+	// 换尺寸后旧尺寸的 alpha 不再保留
+	m.alpha(image.Pt(30, 30))
+	if m.alphaSize != image.Pt(30, 30) {
+		t.Fatalf("cached size = %v, want the latest size", m.alphaSize)
+	}
+	if got := m.alpha(image.Pt(20, 20)); got == first || got.Bounds().Dx() != 20 {
+		t.Fatalf("old size should be rebuilt, not kept around")
+	}
+}
+
+func writeMaskPNG(t *testing.T) string {
+	t.Helper()
+	// 左半边不透明，右半边透明
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{A: 255})
+		}
+	}
+	path := filepath.Join(t.TempDir(), "mask.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSetMask_ImageMask(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	p := NewPlayer(a, w)
+	if err := p.SetMask(MaskSettings{Kind: MaskImage, Path: filepath.Join(t.TempDir(), "missing.png")}); err == nil {
+		t.Fatalf("missing mask file should fail")
+	}
+	if p.Mask() != (MaskSettings{}) {
+		t.Fatalf("failed SetMask should keep the previous mask")
+	}
+
+	path := writeMaskPNG(t)
+	if err := p.SetMask(MaskSettings{Kind: MaskImage, Path: path, Radius: 0.3}); err != nil {
+		t.Fatalf("SetMask: %v", err)
+	}
+	id := p.beginPlayback()
+	out := p.renderFrame(id, 0, solidRGBA(16, 16, color.RGBA{0, 255, 0, 255}), true)
+	assertRGBA(t, out.At(1, 8), color.RGBA{0, 255, 0, 255})
+	assertRGBA(t, out.At(14, 8), color.RGBA{})
+
+	if d, ok := p.DistanceToContent(10, 50, 100, 100); !ok || d != 0 {
+		t.Fatalf("point on the opaque half should be inside, got %v %v", d, ok)
+	}
+	if d, _ := p.DistanceToContent(90, 50, 100, 100); d < 35 || d > 45 {
+		t.Fatalf("distance from the transparent half = %v, want about 40", d)
+	}
+
+	if err := p.SetMask(MaskSettings{}); err != nil {
+		t.Fatalf("clearing the mask: %v", err)
+	}
+	if _, ok := p.DistanceToContent(90, 50, 100, 100); ok {
+		t.Fatalf("without a mask the caller should fall back to the window rect")
+	}
+	p.Close()
+}
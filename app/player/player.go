@@ -2,14 +2,12 @@ package player
 
 import (
 	"image"
-	_ "image/jpeg"
-	_ "image/png"
 	"log"
 	"math"
 	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
-	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -30,17 +28,45 @@ const (
 const (
 	pauseReasonDrag uint32 = 1 << iota
 	pauseReasonFullyTransparent
+	pauseReasonUser
 )
 
 type Player struct {
-	app          fyne.App
-	Canvas       *canvas.Image
-	window       fyne.Window
-	pauseReasons atomic.Uint32
-	playbackID   atomic.Uint64
-	pauseSignal  chan struct{}
-	baseSize     fyne.Size
-	zoom         float32
+	app             fyne.App
+	Canvas          *canvas.Image
+	window          fyne.Window
+	pauseReasons    atomic.Uint32
+	playbackID      atomic.Uint64
+	pauseSignal     chan struct{}
+	baseSize        fyne.Size
+	zoom            float32
+	renderMu        sync.Mutex
+	renderSize      image.Point
+	renderOwner     uint64
+	renderCache     map[int]image.Image
+	renderFilters   FilterSettings
+	renderMask      *maskState
+	renderCrop      CropRect
+	renderTransform ImageTransform
+	currentFrame    shownFrame
+	settingsMu      sync.Mutex
+	loop            LoopSettings
+	speed           float64
+	transition      TransitionSettings
+	pending         *pendingTransition
+	kenBurns        KenBurnsSettings
+	kenBurnsRand    func() float64
+	currentPath     string
+	replayID        uint64
+	replayIndex     int
+	anim            *Animation
+	animID          uint64
+	animIndex       int
+	animStepped     bool
+	lifeMu          sync.Mutex
+	playback        *playback
+	closed          bool
+	running         atomic.Int32
 }
 
 var getScreenWidthPixels = platform.GetScreenWidthPixels
@@ -77,27 +103,56 @@ func (p *Player) Play(path string) {
 	if path == "" {
 		return
 	}
+	if p.isClosed() {
+		return
+	}
 	if _, err := os.Stat(path); err != nil {
 		log.Printf("image file not found: %q (%v)", path, err)
 		return
 	}
 
-	lower := strings.ToLower(path)
+	// 先解码再换图：解码期间旧图照常播放，换图时旧的播放被取消后很快就能退出
+	img, err := decodeImageFile(path)
+	if err != nil {
+		log.Printf("decode image %q failed: %v", path, err)
+	}
+
 	playbackID := p.beginPlayback()
-	if strings.HasSuffix(lower, ".gif") {
-		PlayGIF(p, path, playbackID)
-	} else if strings.HasSuffix(lower, ".webp") {
-		PlayWebP(p, path, playbackID)
-	} else if strings.HasSuffix(lower, ".png") || strings.HasSuffix(lower, ".jpg") || strings.HasSuffix(lower, ".jpeg") {
-		PlayImage(p, path, playbackID)
+	p.settingsMu.Lock()
+	replay := p.currentPath == path
+	if replay {
+		// 编辑时改裁剪、旋转、滤镜会重播当前图，接着显示原来那一帧
+		p.replayID = playbackID
+		p.replayIndex = p.animIndex
+	}
+	p.currentPath = path
+	p.settingsMu.Unlock()
+	// 用户暂停只针对当前这张图，换图后正常播放，重播时保持暂停
+	if !replay {
+		p.Resume()
+	}
+	if err == nil {
+		img.show(p, playbackID)
 	}
 
 	// 记录本次播放的图，下次打开app自动用
 	p.app.Preferences().SetString(lastImagePathKey, path)
 }
 
+// CurrentPath 返回最近一次 Play 的图片路径。
+func (p *Player) CurrentPath() string {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	return p.currentPath
+}
+
+// LastPath 返回上次打开 app 时播放的图片路径，PlayLast 会播放它。
+func (p *Player) LastPath() string {
+	return strings.TrimSpace(p.app.Preferences().String(lastImagePathKey))
+}
+
 func (p *Player) PlayLast() {
-	path := strings.TrimSpace(p.app.Preferences().String(lastImagePathKey))
+	path := p.LastPath()
 	if path == "" {
 		return
 	}
@@ -120,17 +175,17 @@ func (p *Player) RenderPaused() bool {
 	return p.pauseReasons.Load() != 0
 }
 
+// waitRenderResumed 阻塞到所有暂停原因解除，播放被取消时立即返回 false。
 func (p *Player) waitRenderResumed(playbackID uint64) bool {
-	ticker := time.NewTicker(200 * time.Millisecond)
-	defer ticker.Stop()
-
+	ctx := p.playbackContext(playbackID)
 	for p.RenderPaused() {
 		if !p.isPlaybackActive(playbackID) {
 			return false
 		}
 		select {
 		case <-p.pauseSignal:
-		case <-ticker.C:
+		case <-ctx.Done():
+			return false
 		}
 	}
 	return p.isPlaybackActive(playbackID)
@@ -225,6 +280,7 @@ func (p *Player) adjustScaleAt(delta float32, anchor fyne.Position) {
 }
 
 func (p *Player) updateBaseSize(width, height int) {
+	width, height = p.displaySize(width, height)
 	if width <= 0 || height <= 0 {
 		return
 	}
@@ -260,6 +316,7 @@ func (p *Player) applyScaledSize() {
 	size := p.scaledSizeForZoom(p.zoom)
 	p.Canvas.Resize(size)
 	p.window.Resize(size)
+	p.setRenderSize(p.renderSizePixels())
 }
 
 func (p *Player) scaledSizeForZoom(zoom float32) fyne.Size {
@@ -332,39 +389,3 @@ func clampPosition(pos fyne.Position, bounds fyne.Size) fyne.Position {
 func toScreenPixels(v, scale float32) int {
 	return int(math.Round(float64(v * scale)))
 }
-
-func (p *Player) beginPlayback() uint64 {
-	return p.playbackID.Add(1)
-}
-
-func (p *Player) isPlaybackActive(id uint64) bool {
-	return p.playbackID.Load() == id
-}
-
-func PlayImage(p *Player, path string, playbackID uint64) {
-	f, err := os.Open(path)
-	if err != nil {
-		log.Printf("open image failed: %v", err)
-		return
-	}
-	defer f.Close()
-
-	img, _, err := image.Decode(f)
-	if err != nil {
-		log.Printf("decode image failed: %v", err)
-		return
-	}
-	if !p.isPlaybackActive(playbackID) {
-		return
-	}
-
-	fyne.Do(func() {
-		if !p.isPlaybackActive(playbackID) {
-			return
-		}
-		b := img.Bounds()
-		p.updateBaseSize(b.Dx(), b.Dy())
-		p.Canvas.Image = img
-		p.Canvas.Refresh()
-	})
-}
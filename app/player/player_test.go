@@ -421,7 +421,7 @@ func TestAdjustScaleByScroll_UsesDXWhenDYZero(t *testing.T) {
 	}
 }
 
-func TestPlayImage_LoadsAndAppliesImage(t *testing.T) {
+func TestPlay_LoadsAndAppliesImage(t *testing.T) {
 	a := fynetest.NewApp()
 	defer a.Quit()
 	w := a.NewWindow("test")
@@ -441,8 +441,7 @@ func TestPlayImage_LoadsAndAppliesImage(t *testing.T) {
 	}
 	_ = file.Close()
 
-	id := p.beginPlayback()
-	PlayImage(p, imgPath, id)
+	p.Play(imgPath)
 	fyne.DoAndWait(func() {})
 
 	if p.Canvas.Image == nil {
@@ -453,7 +452,7 @@ func TestPlayImage_LoadsAndAppliesImage(t *testing.T) {
 	}
 }
 
-func TestPlayImage_IgnoresInactivePlayback(t *testing.T) {
+func TestShowDecoded_IgnoresInactivePlayback(t *testing.T) {
 	a := fynetest.NewApp()
 	defer a.Quit()
 	w := a.NewWindow("test")
@@ -471,8 +470,12 @@ func TestPlayImage_IgnoresInactivePlayback(t *testing.T) {
 	}
 	_ = file.Close()
 
+	img, err := decodeFile(pngDecoder{}, imgPath)
+	if err != nil {
+		t.Fatalf("decodeFile: %v", err)
+	}
 	currentID := p.beginPlayback()
-	PlayImage(p, imgPath, currentID-1)
+	img.show(p, currentID-1)
 	fyne.DoAndWait(func() {})
 
 	if p.Canvas.Image != nil {
@@ -0,0 +1,160 @@
+package player
+
+import (
+	"context"
+	"image"
+	"math"
+
+	"fyne.io/fyne/v2"
+	xdraw "golang.org/x/image/draw"
+)
+
+// 预缩放用的滤波器，缩小时会按比例扩大采样范围，效果接近面积平均
+var scaleFilter xdraw.Interpolator = xdraw.BiLinear
+
+// ScalableImage 是可以按任意尺寸重新栅格化的图片（比如 SVG）。
+// 解码器的 DecodeStill 返回它时，播放器会按屏幕像素尺寸直接渲染，而不是缩放位图。
+type ScalableImage interface {
+	image.Image
+	RenderAt(size image.Point) image.Image
+}
+
+type shownFrame struct {
+	playbackID uint64
+	index      int
+	src        image.Image
+	cacheable  bool
+}
+
+// renderSizePixels 返回画布当前的物理像素尺寸。
+func (p *Player) renderSizePixels() image.Point {
+	size := p.scaledSizeForZoom(p.zoom)
+	scale := float32(1.0)
+	if p.window != nil {
+		if c := p.window.Canvas(); c != nil && c.Scale() > 0 {
+			scale = c.Scale()
+		}
+	}
+	return image.Pt(toScreenPixels(size.Width, scale), toScreenPixels(size.Height, scale))
+}
+
+// setRenderSize 在缩放变化后调用。尺寸变了就丢掉已缩放的帧缓存，并在后台重新缩放当前帧，
+// 暂停中的动图和静态图也能及时变清晰。
+func (p *Player) setRenderSize(size image.Point) {
+	p.updateRender(func() bool {
+		if p.renderSize == size {
+			return false
+		}
+		p.renderSize = size
+		return true
+	})
+}
+
+// updateRender 在 renderMu 下修改渲染参数，change 返回 true 表示有变化，此时清空缓存并重新渲染当前帧。
+func (p *Player) updateRender(change func() bool) {
+	p.renderMu.Lock()
+	if !change() {
+		p.renderMu.Unlock()
+		return
+	}
+	p.renderCache = nil
+	current := p.currentFrame
+	p.renderMu.Unlock()
+
+	if current.src == nil || !p.isPlaybackActive(current.playbackID) {
+		return
+	}
+	p.goPlayback(current.playbackID, func(context.Context) {
+		p.rerenderFrame(current)
+	})
+}
+
+func (p *Player) rerenderFrame(frame shownFrame) {
+	img := p.renderFrame(frame.playbackID, frame.index, frame.src, frame.cacheable)
+	p.renderMu.Lock()
+	stillCurrent := p.currentFrame.playbackID == frame.playbackID && p.currentFrame.index == frame.index
+	p.renderMu.Unlock()
+	if !stillCurrent {
+		return
+	}
+
+	fyne.Do(func() {
+		if !p.isPlaybackActive(frame.playbackID) {
+			return
+		}
+		p.Canvas.Image = img
+		p.Canvas.Refresh()
+	})
+}
+
+// renderFrame 把解码出的帧裁剪、缩放到画布的像素尺寸，再旋转并应用滤镜和遮罩。cacheable 为 true 时按帧序号缓存结果，
+// 任何一项渲染设置变化时缓存失效，下次用到时再重新缩放。
+func (p *Player) renderFrame(playbackID uint64, index int, src image.Image, cacheable bool) image.Image {
+	if src == nil {
+		return nil
+	}
+
+	p.renderMu.Lock()
+	size := p.renderSize
+	filters := p.renderFilters
+	mask := p.renderMask
+	crop := p.renderCrop
+	transform := p.renderTransform
+	if p.renderOwner != playbackID {
+		p.renderOwner = playbackID
+		p.renderCache = nil
+	}
+	if cached, ok := p.renderCache[index]; ok && cacheable {
+		p.currentFrame = shownFrame{playbackID: playbackID, index: index, src: src, cacheable: cacheable}
+		p.renderMu.Unlock()
+		return cached
+	}
+	p.renderMu.Unlock()
+
+	// 先缩放再旋转，旋转的像素更少；旋转 90° 时按宽高互换后的尺寸缩放
+	box := size
+	if transform.swapsSize() {
+		box = image.Pt(size.Y, size.X)
+	}
+	out := scaleImageToFit(cropImage(src, crop), box)
+	out = applyMask(applyFilters(transformImage(out, transform), filters), mask)
+
+	p.renderMu.Lock()
+	defer p.renderMu.Unlock()
+	p.currentFrame = shownFrame{playbackID: playbackID, index: index, src: src, cacheable: cacheable}
+	if cacheable && p.renderOwner == playbackID && p.renderSize == size && p.renderFilters == filters && p.renderMask == mask && p.renderCrop == crop && p.renderTransform == transform {
+		if p.renderCache == nil {
+			p.renderCache = make(map[int]image.Image)
+		}
+		p.renderCache[index] = out
+	}
+	return out
+}
+
+// scaleImageToFit 把图片按比例缩小到能放进 size 的尺寸。
+// 位图只缩小不放大：放大交给 canvas 处理，避免缓存占用数倍内存。矢量图按目标尺寸重新栅格化。
+func scaleImageToFit(src image.Image, size image.Point) image.Image {
+	b := src.Bounds()
+	if size.X <= 0 || size.Y <= 0 || b.Dx() <= 0 || b.Dy() <= 0 {
+		return src
+	}
+	if s, ok := src.(ScalableImage); ok {
+		return s.RenderAt(fitSize(b.Size(), size))
+	}
+	if size.X >= b.Dx() && size.Y >= b.Dy() {
+		return src
+	}
+
+	fit := fitSize(b.Size(), size)
+	dst := image.NewRGBA(image.Rect(0, 0, fit.X, fit.Y))
+	scaleFilter.Scale(dst, dst.Bounds(), src, b, xdraw.Src, nil)
+	return dst
+}
+
+// fitSize 返回把 src 等比缩放后刚好放进 box 的尺寸。
+func fitSize(src, box image.Point) image.Point {
+	ratio := math.Min(float64(box.X)/float64(src.X), float64(box.Y)/float64(src.Y))
+	w := int(math.Round(float64(src.X) * ratio))
+	h := int(math.Round(float64(src.Y) * ratio))
+	return image.Pt(max(w, 1), max(h, 1))
+}
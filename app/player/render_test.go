@@ -0,0 +1,102 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestScaleImageToFit(t *testing.T) {
+	t.Parallel()
+
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for i := range src.Pix {
+		src.Pix[i] = 255
+	}
+
+	tests := []struct {
+		name string
+		size image.Point
+		want image.Point
+		same bool
+	}{
+		{name: "downscale keeps aspect", size: image.Pt(100, 100), want: image.Pt(100, 50)},
+		{name: "exact target", size: image.Pt(200, 100), want: image.Pt(200, 100)},
+		{name: "never upscale", size: image.Pt(800, 400), same: true},
+		{name: "zero size keeps source", size: image.Point{}, same: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := scaleImageToFit(src, tc.size)
+			if tc.same {
+				if got != image.Image(src) {
+					t.Fatalf("scaleImageToFit should return source unchanged")
+				}
+				return
+			}
+			if got.Bounds().Size() != tc.want {
+				t.Fatalf("scaled size = %v, want %v", got.Bounds().Size(), tc.want)
+			}
+			assertRGBA(t, got.At(tc.want.X/2, tc.want.Y/2), color.RGBA{255, 255, 255, 255})
+		})
+	}
+}
+
+func TestRenderFrame_CachesUntilSizeChanges(t *testing.T) {
+	t.Parallel()
+
+	p := &Player{}
+	id := p.beginPlayback()
+	p.setRenderSize(image.Pt(10, 10))
+	src := image.NewRGBA(image.Rect(0, 0, 100, 100))
+
+	first := p.renderFrame(id, 0, src, true)
+	if first.Bounds().Dx() != 10 {
+		t.Fatalf("rendered width = %d, want 10", first.Bounds().Dx())
+	}
+	if again := p.renderFrame(id, 0, src, true); again != first {
+		t.Fatalf("cacheable frame should be reused while size is unchanged")
+	}
+	if uncached := p.renderFrame(id, 1, src, false); uncached == p.renderFrame(id, 1, src, false) {
+		t.Fatalf("non-cacheable frame should be rendered every time")
+	}
+
+	// 这里只测缓存；没有画布，不触发后台重新渲染当前帧
+	p.clearCurrentFrame()
+	p.setRenderSize(image.Pt(20, 20))
+	resized := p.renderFrame(id, 0, src, true)
+	if resized == first || resized.Bounds().Dx() != 20 {
+		t.Fatalf("size change should invalidate the cache, got width %d", resized.Bounds().Dx())
+	}
+
+	next := p.beginPlayback()
+	if other := p.renderFrame(next, 0, src, true); other == resized {
+		t.Fatalf("a new playback should not reuse frames of the previous one")
+	}
+}
+
+func TestShowStillImage_PreScalesToCanvasPixels(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	a.Preferences().SetFloat(lastCanvasWidthKey, 100)
+	p := NewPlayer(a, w)
+	id := p.beginPlayback()
+	showStillImage(p, image.NewRGBA(image.Rect(0, 0, 400, 200)), id)
+	fyne.DoAndWait(func() {})
+
+	if got := p.Canvas.Image.Bounds().Size(); got != image.Pt(100, 50) {
+		t.Fatalf("canvas image size = %v, want (100,50)", got)
+	}
+	if p.baseSize.Width != 400 || p.baseSize.Height != 200 {
+		t.Fatalf("base size should keep the native resolution, got %v", p.baseSize)
+	}
+}
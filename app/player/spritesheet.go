@@ -0,0 +1,161 @@
+package player
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// 没写 fps 和帧时长时每帧显示的时间
+const defaultSpriteFrameDelay = 100 * time.Millisecond
+
+// SpriteSheet 是精灵图旁边的描述文件。图片 walk.png 对应 walk.sprite.json 或 walk.sprite.toml，
+// 描述文件的扩展名固定小写。
+// 帧从左到右、从上到下排列。
+type SpriteSheet struct {
+	FrameWidth  int `json:"frame_width" toml:"frame_width"`
+	FrameHeight int `json:"frame_height" toml:"frame_height"`
+	// Columns、Rows 和帧宽高只写一组即可，另一组按图片尺寸推算
+	Columns int `json:"columns" toml:"columns"`
+	Rows    int `json:"rows" toml:"rows"`
+	// Frames 为 0 时使用整张图的所有格子，最后一行没排满时要写上
+	Frames int     `json:"frames" toml:"frames"`
+	FPS    float64 `json:"fps" toml:"fps"`
+	// Durations 是每帧的毫秒数，优先于 FPS，不够长时剩下的帧按 FPS
+	Durations []int `json:"durations" toml:"durations"`
+	// Loop 是总播放次数，0 表示无限循环，和 Animation.LoopCount 一致
+	Loop int `json:"loop" toml:"loop"`
+}
+
+var errInvalidSpriteSheet = errors.New("sprite sheet: invalid layout")
+
+var spriteSheetSidecarExts = []string{".sprite.json", ".sprite.toml"}
+
+// SpriteSheetSidecar 返回图片对应的描述文件路径，没有时 ok 为 false。
+func SpriteSheetSidecar(path string) (string, bool) {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range spriteSheetSidecarExts {
+		sidecar := base + ext
+		if info, err := os.Stat(sidecar); err == nil && !info.IsDir() {
+			return sidecar, true
+		}
+	}
+	return "", false
+}
+
+// IsSpriteSheetSidecar 判断文件是否为精灵图的描述文件，文件夹扫描时跳过它们。
+// 和 SpriteSheetSidecar 的规则一致，扩展名区分大小写。
+func IsSpriteSheetSidecar(path string) bool {
+	for _, ext := range spriteSheetSidecarExts {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadSpriteSheet(sidecar string) (SpriteSheet, error) {
+	var s SpriteSheet
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return s, err
+	}
+	if strings.HasSuffix(strings.ToLower(sidecar), ".toml") {
+		err = toml.Unmarshal(data, &s)
+	} else {
+		err = json.Unmarshal(data, &s)
+	}
+	return s, err
+}
+
+// layout 按图片尺寸补全帧宽高和行列数。
+func (s SpriteSheet) layout(width, height int) (SpriteSheet, error) {
+	if s.FrameWidth <= 0 && s.Columns > 0 {
+		s.FrameWidth = width / s.Columns
+	}
+	if s.FrameHeight <= 0 && s.Rows > 0 {
+		s.FrameHeight = height / s.Rows
+	}
+	if s.FrameWidth <= 0 || s.FrameHeight <= 0 || s.FrameWidth > width || s.FrameHeight > height {
+		return s, fmt.Errorf("%w: frame %dx%d in %dx%d image", errInvalidSpriteSheet, s.FrameWidth, s.FrameHeight, width, height)
+	}
+	if s.Columns <= 0 || s.Columns*s.FrameWidth > width {
+		s.Columns = width / s.FrameWidth
+	}
+	if s.Rows <= 0 || s.Rows*s.FrameHeight > height {
+		s.Rows = height / s.FrameHeight
+	}
+	if s.Frames <= 0 || s.Frames > s.Columns*s.Rows {
+		s.Frames = s.Columns * s.Rows
+	}
+	return s, nil
+}
+
+func (s SpriteSheet) delayAt(i int) time.Duration {
+	if i < len(s.Durations) && s.Durations[i] > 0 {
+		return time.Duration(s.Durations[i]) * time.Millisecond
+	}
+	if s.FPS > 0 {
+		return time.Duration(float64(time.Second) / s.FPS)
+	}
+	return defaultSpriteFrameDelay
+}
+
+// spriteSheetAnimation 把整张图切成帧，交给和 GIF 相同的帧循环播放。
+func spriteSheetAnimation(sheet image.Image, s SpriteSheet) (*Animation, error) {
+	b := sheet.Bounds()
+	s, err := s.layout(b.Dx(), b.Dy())
+	if err != nil {
+		return nil, err
+	}
+	full := copyToRGBA(sheet)
+	anim := &Animation{
+		Width:     s.FrameWidth,
+		Height:    s.FrameHeight,
+		Frames:    make([]image.Image, s.Frames),
+		Delays:    make([]time.Duration, s.Frames),
+		LoopCount: max(s.Loop, 0),
+	}
+	for i := 0; i < s.Frames; i++ {
+		x := i % s.Columns * s.FrameWidth
+		y := i / s.Columns * s.FrameHeight
+		r := image.Rect(x, y, x+s.FrameWidth, y+s.FrameHeight)
+		anim.Frames[i] = copyToRGBA(full.SubImage(r))
+		anim.Delays[i] = s.delayAt(i)
+	}
+	return anim, nil
+}
+
+// decodeSpriteSheet 把带描述文件的精灵图解码成动图，描述文件有误时按普通图片显示。
+func decodeSpriteSheet(d Decoder, path, sidecar string) (decodedImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return decodedImage{}, err
+	}
+	if err := checkImageSize(d, data); err != nil {
+		return decodedImage{}, err
+	}
+	img, err := d.DecodeStill(bytes.NewReader(data))
+	if err != nil {
+		return decodedImage{}, err
+	}
+
+	s, err := loadSpriteSheet(sidecar)
+	if err == nil {
+		var anim *Animation
+		if anim, err = spriteSheetAnimation(img, s); err == nil {
+			return decodedImage{anim: anim}, nil
+		}
+	}
+	log.Printf("load sprite sheet %q failed, fallback to still image: %v", sidecar, err)
+	return decodedImage{still: img}, nil
+}
@@ -0,0 +1,162 @@
+package player
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestSpriteSheetLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       SpriteSheet
+		want    SpriteSheet
+		wantErr bool
+	}{
+		{"frame size", SpriteSheet{FrameWidth: 10, FrameHeight: 5}, SpriteSheet{FrameWidth: 10, FrameHeight: 5, Columns: 4, Rows: 2, Frames: 8}, false},
+		{"columns rows", SpriteSheet{Columns: 4, Rows: 2}, SpriteSheet{FrameWidth: 10, FrameHeight: 5, Columns: 4, Rows: 2, Frames: 8}, false},
+		{"partial last row", SpriteSheet{Columns: 4, Rows: 2, Frames: 6}, SpriteSheet{FrameWidth: 10, FrameHeight: 5, Columns: 4, Rows: 2, Frames: 6}, false},
+		{"too many frames", SpriteSheet{FrameWidth: 20, FrameHeight: 10, Frames: 9}, SpriteSheet{FrameWidth: 20, FrameHeight: 10, Columns: 2, Rows: 1, Frames: 2}, false},
+		{"missing size", SpriteSheet{Frames: 3}, SpriteSheet{}, true},
+		{"frame larger than image", SpriteSheet{FrameWidth: 50, FrameHeight: 5}, SpriteSheet{}, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tt.s.layout(40, 10)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("layout should fail, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("layout: %v", err)
+			}
+			if got.FrameWidth != tt.want.FrameWidth || got.FrameHeight != tt.want.FrameHeight ||
+				got.Columns != tt.want.Columns || got.Rows != tt.want.Rows || got.Frames != tt.want.Frames {
+				t.Fatalf("layout = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpriteSheetDelays(t *testing.T) {
+	t.Parallel()
+
+	s := SpriteSheet{FPS: 20, Durations: []int{250, 0}}
+	if got := s.delayAt(0); got != 250*time.Millisecond {
+		t.Fatalf("delay 0 = %v, want 250ms", got)
+	}
+	if got := s.delayAt(1); got != 50*time.Millisecond {
+		t.Fatalf("zero duration should fall back to fps, got %v", got)
+	}
+	if got := (SpriteSheet{}).delayAt(3); got != defaultSpriteFrameDelay {
+		t.Fatalf("default delay = %v", got)
+	}
+}
+
+// 2x2 的格子，每格一种颜色
+func spriteSheetImage() *image.RGBA {
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}}
+	img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x++ {
+			img.SetRGBA(x, y, colors[y/3*2+x/4])
+		}
+	}
+	return img
+}
+
+func TestSpriteSheetAnimation_SlicesFrames(t *testing.T) {
+	t.Parallel()
+
+	anim, err := spriteSheetAnimation(spriteSheetImage(), SpriteSheet{Columns: 2, Rows: 2, Frames: 3, Loop: 2})
+	if err != nil {
+		t.Fatalf("spriteSheetAnimation: %v", err)
+	}
+	if anim.Width != 4 || anim.Height != 3 || anim.frameCount() != 3 || anim.LoopCount != 2 {
+		t.Fatalf("animation = %dx%d, %d frames, loop %d", anim.Width, anim.Height, anim.frameCount(), anim.LoopCount)
+	}
+	assertRGBA(t, anim.frameAt(0).At(3, 2), color.RGBA{255, 0, 0, 255})
+	assertRGBA(t, anim.frameAt(1).At(0, 0), color.RGBA{0, 255, 0, 255})
+	assertRGBA(t, anim.frameAt(2).At(1, 1), color.RGBA{0, 0, 255, 255})
+	if b := anim.frameAt(2).Bounds(); b != image.Rect(0, 0, 4, 3) {
+		t.Fatalf("frame bounds = %v", b)
+	}
+}
+
+func TestLoadSpriteSheet_JSONAndTOML(t *testing.T) {
+	t.Parallel()
+
+	jsonPath := writeTestFile(t, "walk.sprite.json", []byte(`{"frame_width": 4, "frame_height": 3, "fps": 12, "durations": [100, 200]}`))
+	tomlPath := writeTestFile(t, "walk.sprite.toml", []byte("frame_width = 4\nframe_height = 3\nfps = 12\ndurations = [100, 200]\n"))
+	for _, path := range []string{jsonPath, tomlPath} {
+		s, err := loadSpriteSheet(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", filepath.Base(path), err)
+		}
+		if s.FrameWidth != 4 || s.FrameHeight != 3 || s.FPS != 12 || len(s.Durations) != 2 || s.Durations[1] != 200 {
+			t.Fatalf("load %s = %+v", filepath.Base(path), s)
+		}
+	}
+	// 大写扩展名的文件不会被当作描述文件，也就不能从文件夹扫描里隐藏
+	if !IsSpriteSheetSidecar(jsonPath) || IsSpriteSheetSidecar(strings.ToUpper(jsonPath)) || IsSpriteSheetSidecar("walk.json") {
+		t.Fatalf("IsSpriteSheetSidecar mismatch")
+	}
+}
+
+func TestPlay_SpriteSheetUsesFrameSize(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, spriteSheetImage()); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	imgPath := filepath.Join(dir, "walk.png")
+	if err := os.WriteFile(imgPath, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write png: %v", err)
+	}
+	if _, ok := SpriteSheetSidecar(imgPath); ok {
+		t.Fatalf("no sidecar yet")
+	}
+	if r := detectImage(imgPath); !r.ok || r.sidecar != "" {
+		t.Fatalf("detectImage = %+v, want a plain png", r)
+	}
+	sidecar := filepath.Join(dir, "walk.sprite.json")
+	if err := os.WriteFile(sidecar, []byte(`{"columns": 2, "rows": 2, "fps": 10}`), 0o644); err != nil {
+		t.Fatalf("write sidecar: %v", err)
+	}
+	if got, ok := SpriteSheetSidecar(imgPath); !ok || got != sidecar {
+		t.Fatalf("SpriteSheetSidecar = %q, %v", got, ok)
+	}
+	// 识别结果有缓存，新加的描述文件也要能被发现
+	if r := detectImage(imgPath); r.sidecar != sidecar {
+		t.Fatalf("detectImage sidecar = %q, want %q", r.sidecar, sidecar)
+	}
+
+	p := NewPlayer(a, w)
+	defer p.Close()
+	p.Play(imgPath)
+	fyne.DoAndWait(func() {})
+
+	if p.baseSize.Width != 4 || p.baseSize.Height != 3 {
+		t.Fatalf("base size = (%v,%v), want one frame (4,3)", p.baseSize.Width, p.baseSize.Height)
+	}
+}
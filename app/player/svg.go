@@ -0,0 +1,134 @@
+package player
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// SVG 没写尺寸时使用的默认边长
+const defaultSVGSize = 512
+
+type svgDecoder struct{}
+
+func (svgDecoder) Name() string { return "svg" }
+
+func (svgDecoder) Extensions() []string { return []string{"svg"} }
+
+// Sniff 跳过 XML 声明、处理指令和注释，看第一个元素是不是 svg。
+func (svgDecoder) Sniff(header []byte) bool {
+	header = bytes.TrimPrefix(header, []byte("\xef\xbb\xbf"))
+	for {
+		header = bytes.TrimLeft(header, " \t\r\n")
+		var end []byte
+		switch {
+		case bytes.HasPrefix(header, []byte("<?")):
+			end = []byte("?>")
+		case bytes.HasPrefix(header, []byte("<!--")):
+			end = []byte("-->")
+		case bytes.HasPrefix(header, []byte("<!DOCTYPE")):
+			return isSVGName(bytes.TrimLeft(header[len("<!DOCTYPE"):], " \t\r\n"))
+		case bytes.HasPrefix(header, []byte("<")):
+			return isSVGName(header[1:])
+		default:
+			return false
+		}
+		i := bytes.Index(header, end)
+		if i < 0 {
+			return false
+		}
+		header = header[i+len(end):]
+	}
+}
+
+// isSVGName 判断 b 是否以元素名 svg 开头，svgfoo 之类不算。
+func isSVGName(b []byte) bool {
+	if !bytes.HasPrefix(b, []byte("svg")) {
+		return false
+	}
+	if len(b) == 3 {
+		return true
+	}
+	switch b[3] {
+	case ' ', '\t', '\r', '\n', '>', '/':
+		return true
+	}
+	return false
+}
+
+func (svgDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	img, err := decodeSVG(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBAModel, Width: img.width, Height: img.height}, nil
+}
+
+func (svgDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	return decodeSVG(r)
+}
+
+func (svgDecoder) DecodeAnimation(io.Reader) (*Animation, error) {
+	return nil, nil
+}
+
+// svgImage 是矢量图，作为 image.Image 使用时按原始尺寸栅格化，
+// 播放器显示时通过 RenderAt 按屏幕像素尺寸重新栅格化，放大也不会糊。
+type svgImage struct {
+	icon          *oksvg.SvgIcon
+	width, height int
+
+	// SvgIcon 的 SetTarget 会改内部状态，栅格化不能并发
+	mu     sync.Mutex
+	once   sync.Once
+	raster *image.RGBA
+}
+
+func decodeSVG(r io.Reader) (*svgImage, error) {
+	icon, err := oksvg.ReadIconStream(r, oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, err
+	}
+	w := int(math.Ceil(icon.ViewBox.W))
+	h := int(math.Ceil(icon.ViewBox.H))
+	if w <= 0 || h <= 0 {
+		w, h = defaultSVGSize, defaultSVGSize
+	}
+	return &svgImage{icon: icon, width: w, height: h}, nil
+}
+
+func (s *svgImage) intrinsic() *image.RGBA {
+	s.once.Do(func() {
+		s.raster = s.rasterize(s.width, s.height)
+	})
+	return s.raster
+}
+
+func (s *svgImage) ColorModel() color.Model { return color.RGBAModel }
+
+func (s *svgImage) Bounds() image.Rectangle { return image.Rect(0, 0, s.width, s.height) }
+
+func (s *svgImage) At(x, y int) color.Color { return s.intrinsic().At(x, y) }
+
+func (s *svgImage) RenderAt(size image.Point) image.Image {
+	if size.X <= 0 || size.Y <= 0 {
+		return s.intrinsic()
+	}
+	return s.rasterize(size.X, size.Y)
+}
+
+func (s *svgImage) rasterize(w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.icon.SetTarget(0, 0, float64(w), float64(h))
+	scanner := rasterx.NewScannerGV(w, h, dst, dst.Bounds())
+	s.icon.Draw(rasterx.NewDasher(w, h, scanner), 1)
+	return dst
+}
@@ -0,0 +1,75 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+const testSVG = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 10" width="20" height="10">
+<rect x="0" y="0" width="20" height="10" fill="#ff0000"/>
+</svg>`
+
+func TestSVGDecoder_DecodeStill(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := svgDecoder{}.DecodeConfig(strings.NewReader(testSVG))
+	if err != nil {
+		t.Fatalf("DecodeConfig error: %v", err)
+	}
+	if cfg.Width != 20 || cfg.Height != 10 {
+		t.Fatalf("config = %dx%d, want 20x10", cfg.Width, cfg.Height)
+	}
+
+	img, err := svgDecoder{}.DecodeStill(strings.NewReader(testSVG))
+	if err != nil {
+		t.Fatalf("DecodeStill error: %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 20, 10) {
+		t.Fatalf("bounds = %v, want 20x10", img.Bounds())
+	}
+	assertRGBA(t, img.At(10, 5), color.RGBA{255, 0, 0, 255})
+}
+
+func TestSVG_RendersAtScreenSize(t *testing.T) {
+	t.Parallel()
+
+	img, err := svgDecoder{}.DecodeStill(strings.NewReader(testSVG))
+	if err != nil {
+		t.Fatalf("DecodeStill error: %v", err)
+	}
+
+	// 矢量图放大时重新栅格化，而不是保持原尺寸交给 canvas 拉伸
+	got := scaleImageToFit(img, image.Pt(200, 200))
+	if got.Bounds().Size() != image.Pt(200, 100) {
+		t.Fatalf("rendered size = %v, want (200,100)", got.Bounds().Size())
+	}
+	assertRGBA(t, got.At(150, 80), color.RGBA{255, 0, 0, 255})
+}
+
+func TestSVGDecoder_Sniff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "<svg xmlns=", want: true},
+		{header: "\xef\xbb\xbf\n  <svg>", want: true},
+		{header: "<!DOCTYPE svg PUBLIC", want: true},
+		{header: "<?xml version=\"1.0\"?>\n<svg width=\"10\">", want: true},
+		{header: "<?xml version=\"1.0\"?>\n<!-- Created with Inkscape -->\n<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\">\n<svg>", want: true},
+		{header: "<!-- <svg> in a comment --><html>", want: false},
+		{header: "<?xml version=\"1.0\"?>", want: false},
+		{header: "<?xml version=\"1.0\"?><!-- cut off", want: false},
+		{header: "<svgfoo>", want: false},
+		{header: "<html>", want: false},
+	}
+
+	for _, tc := range tests {
+		if got := (svgDecoder{}).Sniff([]byte(tc.header)); got != tc.want {
+			t.Fatalf("Sniff(%q) = %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"time"
+
+	"golang.org/x/image/tiff"
+)
+
+// 多页 TIFF 按幻灯片播放，每页停留的时长
+const tiffPageDelay = 3 * time.Second
+
+// 防止损坏文件的 IFD 链成环
+const maxTIFFPages = 1024
+
+var errInvalidTIFF = errors.New("tiff: invalid multi-page tiff")
+
+type tiffDecoder struct{}
+
+func (tiffDecoder) Name() string { return "tiff" }
+
+func (tiffDecoder) Extensions() []string { return []string{"tiff", "tif"} }
+
+func (tiffDecoder) Sniff(header []byte) bool {
+	return bytes.HasPrefix(header, []byte("II*\x00")) || bytes.HasPrefix(header, []byte("MM\x00*"))
+}
+
+func (tiffDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	return tiff.DecodeConfig(r)
+}
+
+func (tiffDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	return tiff.Decode(r)
+}
+
+func (tiffDecoder) DecodeAnimation(r io.Reader) (*Animation, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := tiffPageOffsets(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(offsets) <= 1 {
+		return nil, nil
+	}
+	if limit := currentStreamingOptions().ThresholdBytes; limit > 0 && tiffPagesBytes(data, offsets) > limit {
+		// 页太多太大时全部解码会占用大量内存，只显示第一页
+		return nil, nil
+	}
+	return decodeTIFFPages(data, offsets)
+}
+
+// tiffPagesBytes 只读各页的尺寸，估算全部解码成 RGBA 需要的内存。尺寸不一的页会放到最大尺寸的画布上，按最大尺寸算。
+func tiffPagesBytes(data []byte, offsets []uint32) int64 {
+	order, _ := tiffByteOrder(data)
+	patched := make([]byte, len(data))
+	copy(patched, data)
+
+	var width, height, pages int64
+	for _, off := range offsets {
+		order.PutUint32(patched[4:8], off)
+		c, err := tiff.DecodeConfig(bytes.NewReader(patched))
+		if err != nil {
+			continue
+		}
+		width = max(width, int64(c.Width))
+		height = max(height, int64(c.Height))
+		pages++
+	}
+	return width * height * 4 * pages
+}
+
+func tiffByteOrder(data []byte) (binary.ByteOrder, bool) {
+	if len(data) < 8 {
+		return nil, false
+	}
+	switch string(data[0:4]) {
+	case "II*\x00":
+		return binary.LittleEndian, true
+	case "MM\x00*":
+		return binary.BigEndian, true
+	}
+	return nil, false
+}
+
+// tiffPageOffsets 沿 IFD 链找出每一页的 IFD 偏移。
+func tiffPageOffsets(data []byte) ([]uint32, error) {
+	order, ok := tiffByteOrder(data)
+	if !ok {
+		return nil, errInvalidTIFF
+	}
+
+	var offsets []uint32
+	seen := make(map[uint32]bool)
+	next := order.Uint32(data[4:8])
+	for next != 0 && len(offsets) < maxTIFFPages {
+		if seen[next] || int64(next)+2 > int64(len(data)) {
+			break
+		}
+		seen[next] = true
+		offsets = append(offsets, next)
+
+		count := int64(order.Uint16(data[next : next+2]))
+		linkAt := int64(next) + 2 + count*12
+		if linkAt+4 > int64(len(data)) {
+			break
+		}
+		next = order.Uint32(data[linkAt : linkAt+4])
+	}
+	if len(offsets) == 0 {
+		return nil, errInvalidTIFF
+	}
+	return offsets, nil
+}
+
+// decodeTIFFPages 逐页解码。x/image/tiff 只解第一个 IFD，所以把文件头里的首个 IFD 偏移
+// 改成目标页再解码。页面尺寸不一致时居中放到最大尺寸的画布上。
+func decodeTIFFPages(data []byte, offsets []uint32) (*Animation, error) {
+	order, _ := tiffByteOrder(data)
+	patched := make([]byte, len(data))
+	copy(patched, data)
+
+	pages := make([]image.Image, 0, len(offsets))
+	var width, height int
+	for _, off := range offsets {
+		order.PutUint32(patched[4:8], off)
+		page, err := tiff.Decode(bytes.NewReader(patched))
+		if err != nil {
+			// 跳过无法解码的页，至少保证其它页能看
+			continue
+		}
+		pages = append(pages, page)
+		b := page.Bounds()
+		width = max(width, b.Dx())
+		height = max(height, b.Dy())
+	}
+	if len(pages) == 0 {
+		return nil, errInvalidTIFF
+	}
+	if len(pages) == 1 {
+		return nil, nil
+	}
+
+	anim := &Animation{
+		Width:  width,
+		Height: height,
+		Frames: make([]image.Image, 0, len(pages)),
+		Delays: make([]time.Duration, 0, len(pages)),
+	}
+	bounds := image.Rect(0, 0, width, height)
+	for _, page := range pages {
+		b := page.Bounds()
+		if b.Dx() == width && b.Dy() == height {
+			anim.Frames = append(anim.Frames, page)
+		} else {
+			canvas := image.NewRGBA(bounds)
+			at := image.Pt((width-b.Dx())/2, (height-b.Dy())/2)
+			draw.Draw(canvas, image.Rectangle{Min: at, Max: at.Add(b.Size())}, page, b.Min, draw.Src)
+			anim.Frames = append(anim.Frames, canvas)
+		}
+		anim.Delays = append(anim.Delays, tiffPageDelay)
+	}
+	return anim, nil
+}
@@ -0,0 +1,156 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+func encodeTestTIFF(t *testing.T, w, h int, c color.RGBA) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := tiff.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("encode tiff: %v", err)
+	}
+	return buf.Bytes()
+}
+
+const tiffTagStripOffsets = 273
+
+var tiffTypeSize = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8}
+
+// buildMultiPageTIFF 把多个单页 TIFF 拼成一个文件：后一页整体追加到末尾，
+// 修正它的偏移量，再把上一页 IFD 的 next 指针指向它。
+func buildMultiPageTIFF(t *testing.T, pages ...[]byte) []byte {
+	t.Helper()
+
+	out := append([]byte(nil), pages[0]...)
+	lastIFD := binary.LittleEndian.Uint32(out[4:8])
+	for _, page := range pages[1:] {
+		base := uint32(len(out))
+		shifted := append([]byte(nil), page...)
+		ifd := binary.LittleEndian.Uint32(shifted[4:8])
+		count := int(binary.LittleEndian.Uint16(shifted[ifd : ifd+2]))
+		for i := 0; i < count; i++ {
+			entry := shifted[int(ifd)+2+i*12:]
+			tag := binary.LittleEndian.Uint16(entry[0:2])
+			n := binary.LittleEndian.Uint32(entry[4:8])
+			size := tiffTypeSize[binary.LittleEndian.Uint16(entry[2:4])] * n
+			v := binary.LittleEndian.Uint32(entry[8:12])
+			// 超过 4 字节的值存的是偏移；StripOffsets 的值本身也是偏移
+			if size > 4 || tag == tiffTagStripOffsets {
+				binary.LittleEndian.PutUint32(entry[8:12], v+base)
+			}
+			if size > 4 && tag == tiffTagStripOffsets {
+				for k := uint32(0); k < n; k++ {
+					at := v + k*4
+					o := binary.LittleEndian.Uint32(shifted[at : at+4])
+					binary.LittleEndian.PutUint32(shifted[at:at+4], o+base)
+				}
+			}
+		}
+		out = append(out, shifted...)
+
+		count0 := uint32(binary.LittleEndian.Uint16(out[lastIFD : lastIFD+2]))
+		link := lastIFD + 2 + count0*12
+		binary.LittleEndian.PutUint32(out[link:link+4], ifd+base)
+		lastIFD = ifd + base
+	}
+	return out
+}
+
+func TestTIFFDecoder_MultiPageSlideshow(t *testing.T) {
+	t.Parallel()
+
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	data := buildMultiPageTIFF(t,
+		encodeTestTIFF(t, 4, 2, red),
+		encodeTestTIFF(t, 2, 2, blue),
+	)
+
+	anim, err := tiffDecoder{}.DecodeAnimation(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeAnimation error: %v", err)
+	}
+	if anim.frameCount() != 2 || anim.Width != 4 || anim.Height != 2 {
+		t.Fatalf("anim = %d frames %dx%d, want 2 frames 4x2", anim.frameCount(), anim.Width, anim.Height)
+	}
+	if anim.delayAt(1) != tiffPageDelay {
+		t.Fatalf("page delay = %v, want %v", anim.delayAt(1), tiffPageDelay)
+	}
+	assertRGBA(t, anim.frameAt(0).At(0, 0), red)
+	// 第二页更窄，居中放置，两边透明
+	assertRGBA(t, anim.frameAt(1).At(0, 0), color.RGBA{})
+	assertRGBA(t, anim.frameAt(1).At(1, 0), blue)
+}
+
+func TestTIFFDecoder_SinglePageIsStill(t *testing.T) {
+	t.Parallel()
+
+	data := encodeTestTIFF(t, 2, 2, color.RGBA{0, 255, 0, 255})
+	anim, err := tiffDecoder{}.DecodeAnimation(bytes.NewReader(data))
+	if err != nil || anim != nil {
+		t.Fatalf("single page DecodeAnimation = %v, %v, want nil, nil", anim, err)
+	}
+	img, err := tiffDecoder{}.DecodeStill(bytes.NewReader(data))
+	if err != nil || img.Bounds().Dx() != 2 {
+		t.Fatalf("DecodeStill = %v, %v", img, err)
+	}
+}
+
+func TestTIFFPageOffsets_StopsOnLoop(t *testing.T) {
+	t.Parallel()
+
+	data := encodeTestTIFF(t, 1, 1, color.RGBA{})
+	ifd := binary.LittleEndian.Uint32(data[4:8])
+	count := uint32(binary.LittleEndian.Uint16(data[ifd : ifd+2]))
+	link := ifd + 2 + count*12
+	binary.LittleEndian.PutUint32(data[link:link+4], ifd)
+
+	offsets, err := tiffPageOffsets(data)
+	if err != nil || len(offsets) != 1 {
+		t.Fatalf("tiffPageOffsets = %v, %v, want one page", offsets, err)
+	}
+}
+
+func TestTIFFDecoder_TooLargeShowsFirstPage(t *testing.T) {
+	old := currentStreamingOptions()
+	t.Cleanup(func() { SetStreamingOptions(old) })
+
+	data := buildMultiPageTIFF(t,
+		encodeTestTIFF(t, 4, 2, color.RGBA{255, 0, 0, 255}),
+		encodeTestTIFF(t, 2, 3, color.RGBA{0, 0, 255, 255}),
+		encodeTestTIFF(t, 1, 1, color.RGBA{0, 255, 0, 255}),
+	)
+	offsets, err := tiffPageOffsets(data)
+	if err != nil {
+		t.Fatalf("tiffPageOffsets: %v", err)
+	}
+	// 按最大尺寸 4x3 算，三页共 144 字节
+	if got := tiffPagesBytes(data, offsets); got != 4*3*4*3 {
+		t.Fatalf("tiffPagesBytes = %d, want %d", got, 4*3*4*3)
+	}
+
+	SetStreamingOptions(StreamingOptions{ThresholdBytes: 100})
+	anim, err := tiffDecoder{}.DecodeAnimation(bytes.NewReader(data))
+	if err != nil || anim != nil {
+		t.Fatalf("oversized DecodeAnimation = %v, %v, want nil, nil so only the first page is shown", anim, err)
+	}
+
+	SetStreamingOptions(StreamingOptions{ThresholdBytes: 144})
+	if anim, err := (tiffDecoder{}).DecodeAnimation(bytes.NewReader(data)); err != nil || anim.frameCount() != 3 {
+		t.Fatalf("within the limit DecodeAnimation = %v, %v, want 3 pages", anim, err)
+	}
+}
@@ -0,0 +1,159 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"sync"
+)
+
+// ImageTransform 是显示时的旋转和镜像：先按 Mirror 水平翻转，再顺时针旋转 Quarter 个 90°。
+// 零值表示原样显示。
+type ImageTransform struct {
+	Quarter int
+	Mirror  bool
+}
+
+func (t ImageTransform) normalize() ImageTransform {
+	t.Quarter = ((t.Quarter % 4) + 4) % 4
+	return t
+}
+
+func (t ImageTransform) IsIdentity() bool {
+	return t.normalize() == ImageTransform{}
+}
+
+// Degrees 返回顺时针旋转的角度：0、90、180 或 270。
+func (t ImageTransform) Degrees() int {
+	return t.normalize().Quarter * 90
+}
+
+func TransformFromDegrees(degrees int, mirror bool) ImageTransform {
+	return ImageTransform{Quarter: degrees / 90, Mirror: mirror}.normalize()
+}
+
+func (t ImageTransform) RotateClockwise() ImageTransform {
+	t.Quarter++
+	return t.normalize()
+}
+
+func (t ImageTransform) RotateCounterClockwise() ImageTransform {
+	t.Quarter += 3
+	return t.normalize()
+}
+
+// FlipHorizontal 在当前显示效果上再左右翻转。翻转和旋转不能交换顺序，所以旋转方向要反过来。
+func (t ImageTransform) FlipHorizontal() ImageTransform {
+	return ImageTransform{Quarter: -t.Quarter, Mirror: !t.Mirror}.normalize()
+}
+
+// FlipVertical 在当前显示效果上再上下翻转，相当于左右翻转后转 180°。
+func (t ImageTransform) FlipVertical() ImageTransform {
+	return ImageTransform{Quarter: 2 - t.Quarter, Mirror: !t.Mirror}.normalize()
+}
+
+// orientation 返回效果相同的 EXIF Orientation，复用 applyOrientation 处理像素。
+func (t ImageTransform) orientation() int {
+	t = t.normalize()
+	if t.Mirror {
+		return [4]int{2, 7, 4, 5}[t.Quarter]
+	}
+	return [4]int{1, 6, 3, 8}[t.Quarter]
+}
+
+func (t ImageTransform) swapsSize() bool {
+	return t.normalize().Quarter%2 == 1
+}
+
+// UnmapRect 把显示画面上的区域换算回变换前的画面，坐标都是 0~1 的比例。
+func (t ImageTransform) UnmapRect(r CropRect) CropRect {
+	t = t.normalize()
+	unmap := func(u, v float64) (float64, float64) {
+		// 顺时针转 90° 把 (s, t) 映射到 (1-t, s)，这里反过来转
+		for i := 0; i < t.Quarter; i++ {
+			u, v = v, 1-u
+		}
+		if t.Mirror {
+			u = 1 - u
+		}
+		return u, v
+	}
+	x1, y1 := unmap(r.X, r.Y)
+	x2, y2 := unmap(r.X+r.W, r.Y+r.H)
+	return CropRect{
+		X: min(x1, x2),
+		Y: min(y1, y2),
+		W: max(x1, x2) - min(x1, x2),
+		H: max(y1, y2) - min(y1, y2),
+	}
+}
+
+// SetTransform 修改旋转和镜像，当前画面立即重新渲染。窗口尺寸在下次播放时按变换后的尺寸更新。
+func (p *Player) SetTransform(t ImageTransform) {
+	t = t.normalize()
+	p.updateRender(func() bool {
+		if p.renderTransform == t {
+			return false
+		}
+		p.renderTransform = t
+		return true
+	})
+}
+
+func (p *Player) Transform() ImageTransform {
+	p.renderMu.Lock()
+	defer p.renderMu.Unlock()
+	return p.renderTransform
+}
+
+// displaySize 返回 width x height 的原图裁剪、旋转后显示的尺寸。
+func (p *Player) displaySize(width, height int) (int, int) {
+	p.renderMu.Lock()
+	crop, t := p.renderCrop, p.renderTransform
+	p.renderMu.Unlock()
+	width, height = croppedSize(width, height, crop)
+	if t.swapsSize() {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// transformImage 返回旋转、镜像后的图，矢量图变换后仍可按任意尺寸栅格化。
+func transformImage(src image.Image, t ImageTransform) image.Image {
+	if src == nil || t.IsIdentity() {
+		return src
+	}
+	if s, ok := src.(ScalableImage); ok {
+		return &transformedScalable{ScalableImage: s, transform: t.normalize()}
+	}
+	return applyOrientation(src, t.orientation())
+}
+
+type transformedScalable struct {
+	ScalableImage
+	transform ImageTransform
+
+	once      sync.Once
+	intrinsic image.Image
+}
+
+func (s *transformedScalable) Bounds() image.Rectangle {
+	b := s.ScalableImage.Bounds()
+	if s.transform.swapsSize() {
+		return image.Rect(0, 0, b.Dy(), b.Dx())
+	}
+	return image.Rect(0, 0, b.Dx(), b.Dy())
+}
+
+func (s *transformedScalable) At(x, y int) color.Color {
+	s.once.Do(func() {
+		s.intrinsic = s.RenderAt(s.Bounds().Size())
+	})
+	return s.intrinsic.At(x, y)
+}
+
+func (s *transformedScalable) RenderAt(size image.Point) image.Image {
+	if s.transform.swapsSize() {
+		size = image.Pt(size.Y, size.X)
+	}
+	return applyOrientation(s.ScalableImage.RenderAt(size), s.transform.orientation())
+}
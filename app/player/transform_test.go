@@ -0,0 +1,96 @@
+package player
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+// 2x1 的图，左红右蓝
+func twoPixelImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	img.SetRGBA(1, 0, color.RGBA{0, 0, 255, 255})
+	return img
+}
+
+func TestImageTransform_Operations(t *testing.T) {
+	t.Parallel()
+
+	var tr ImageTransform
+	if !tr.RotateClockwise().RotateCounterClockwise().IsIdentity() {
+		t.Fatalf("rotating back and forth should be identity")
+	}
+	if !tr.FlipHorizontal().FlipHorizontal().IsIdentity() || !tr.FlipVertical().FlipVertical().IsIdentity() {
+		t.Fatalf("flipping twice should be identity")
+	}
+	if got := tr.FlipHorizontal().FlipVertical(); got != (ImageTransform{Quarter: 2}) {
+		t.Fatalf("horizontal + vertical flip = %+v, want 180°", got)
+	}
+	if got := TransformFromDegrees(-90, false); got.Degrees() != 270 {
+		t.Fatalf("-90° should normalize to 270°, got %d", got.Degrees())
+	}
+
+	// 顺时针转 90° 后再左右翻转：红色原本在左，转完在上，左右翻转不影响上下
+	img := transformImage(twoPixelImage(), tr.RotateClockwise().FlipHorizontal())
+	if img.Bounds().Size() != image.Pt(1, 2) {
+		t.Fatalf("bounds = %v, want 1x2", img.Bounds())
+	}
+	assertRGBA(t, img.At(0, 0), color.RGBA{255, 0, 0, 255})
+
+	// 先左右翻转再顺时针转 90°：蓝色到了左边再转到上面
+	img = transformImage(twoPixelImage(), tr.FlipHorizontal().RotateClockwise())
+	assertRGBA(t, img.At(0, 0), color.RGBA{0, 0, 255, 255})
+}
+
+func TestImageTransform_UnmapRect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		tr   ImageTransform
+		want CropRect
+	}{
+		{"identity", ImageTransform{}, CropRect{X: 0, Y: 0, W: 0.5, H: 0.25}},
+		{"clockwise", ImageTransform{Quarter: 1}, CropRect{X: 0, Y: 0.5, W: 0.25, H: 0.5}},
+		{"mirror", ImageTransform{Mirror: true}, CropRect{X: 0.5, Y: 0, W: 0.5, H: 0.25}},
+		{"half turn", ImageTransform{Quarter: 2}, CropRect{X: 0.5, Y: 0.75, W: 0.5, H: 0.25}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// 显示画面的左上角区域
+			if got := tt.tr.UnmapRect(CropRect{W: 0.5, H: 0.25}); got != tt.want {
+				t.Fatalf("UnmapRect = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderFrame_RotatesAndSwapsBaseSize(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	p := NewPlayer(a, w)
+	p.SetTransform(ImageTransform{Quarter: 1})
+	fyne.DoAndWait(func() {
+		p.updateBaseSize(200, 100)
+	})
+	if p.baseSize != fyne.NewSize(100, 200) {
+		t.Fatalf("baseSize = %v, want 100x200", p.baseSize)
+	}
+
+	id := p.beginPlayback()
+	out := p.renderFrame(id, 0, twoPixelImage(), true)
+	if out.Bounds().Size() != image.Pt(1, 2) {
+		t.Fatalf("rendered bounds = %v, want 1x2", out.Bounds())
+	}
+	assertRGBA(t, out.At(0, 0), color.RGBA{255, 0, 0, 255})
+	p.Close()
+}
@@ -0,0 +1,239 @@
+package player
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/haua/futu/app/platform"
+	xdraw "golang.org/x/image/draw"
+)
+
+// TransitionKind 是换图时的过渡效果。
+type TransitionKind int
+
+const (
+	TransitionNone TransitionKind = iota
+	TransitionCrossfade
+	TransitionSlide
+	TransitionZoom
+)
+
+const (
+	DefaultTransitionDuration = 400 * time.Millisecond
+	MaxTransitionDuration     = 3 * time.Second
+	transitionFrameInterval   = time.Second / 60
+	// zoom 过渡中新图从这个比例放大到原尺寸
+	transitionZoomStart = 0.6
+)
+
+// TransitionSettings 是换图过渡的方式和时长，零值表示直接切换。
+type TransitionSettings struct {
+	Kind     TransitionKind
+	Duration time.Duration
+}
+
+func (p *Player) SetTransition(s TransitionSettings) {
+	s.Duration = min(max(s.Duration, 0), MaxTransitionDuration)
+	p.settingsMu.Lock()
+	p.transition = s
+	p.settingsMu.Unlock()
+}
+
+func (p *Player) Transition() TransitionSettings {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	return p.transition
+}
+
+// pendingTransition 记录换图前的画面和窗口尺寸、位置，等新图第一帧渲染好后从它过渡过去。
+type pendingTransition struct {
+	playbackID uint64
+	settings   TransitionSettings
+	from       image.Image
+	fromSize   fyne.Size
+	toSize     fyne.Size
+	fromPos    fyne.Position
+	toPos      fyne.Position
+	canMove    bool
+}
+
+// updateBaseSizeWithTransition 在 UI 线程调用，代替 updateBaseSize。
+// 需要过渡时先算好新尺寸，再把窗口恢复成旧尺寸，由 runTransition 逐步变过去。
+func (p *Player) updateBaseSizeWithTransition(playbackID uint64, width, height int) {
+	settings := p.Transition()
+	if p.isReplay(playbackID) {
+		// 同一张图只是改了设置后重新播放，不需要过渡
+		settings.Kind = TransitionNone
+	}
+	from := p.Canvas.Image
+	fromSize := p.Canvas.Size()
+	fromPos, canMove := platform.GetWindowPosition(p.window)
+
+	p.updateBaseSize(width, height)
+
+	p.settingsMu.Lock()
+	p.pending = nil
+	p.settingsMu.Unlock()
+	if settings.Kind == TransitionNone || settings.Duration <= 0 || from == nil {
+		return
+	}
+
+	t := &pendingTransition{
+		playbackID: playbackID,
+		settings:   settings,
+		from:       from,
+		fromSize:   fromSize,
+		toSize:     p.Canvas.Size(),
+		fromPos:    fromPos,
+		canMove:    canMove,
+	}
+	if canMove {
+		t.toPos, _ = platform.GetWindowPosition(p.window)
+		platform.MoveWindowTo(p.window, fromPos.X, fromPos.Y)
+	}
+	// 只恢复窗口尺寸，渲染尺寸保持新图的，过渡中合成的画面不用重新缩放
+	p.Canvas.Resize(fromSize)
+	p.window.Resize(fromSize)
+
+	p.settingsMu.Lock()
+	p.pending = t
+	p.settingsMu.Unlock()
+}
+
+func (p *Player) isReplay(playbackID uint64) bool {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	return p.replayID == playbackID
+}
+
+func (p *Player) hasPendingTransition(playbackID uint64) bool {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	return p.pending != nil && p.pending.playbackID == playbackID
+}
+
+func (p *Player) takePendingTransition(playbackID uint64) *pendingTransition {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	t := p.pending
+	if t == nil || t.playbackID != playbackID {
+		return nil
+	}
+	p.pending = nil
+	return t
+}
+
+// runTransition 把新图的第一帧 to 过渡显示出来，过渡结束（或被取消）后返回。
+// 没有待进行的过渡时返回 false，由调用方直接显示。
+func (p *Player) runTransition(ctx context.Context, playbackID uint64, to image.Image) bool {
+	t := p.takePendingTransition(playbackID)
+	if t == nil || to == nil {
+		return false
+	}
+
+	size := to.Bounds().Size()
+	from := stretchImage(t.from, size)
+	start := time.Now()
+	for {
+		progress := float64(time.Since(start)) / float64(t.settings.Duration)
+		if progress >= 1 {
+			break
+		}
+		eased := easeInOut(progress)
+		frame := composeTransition(t.settings.Kind, from, to, eased)
+		winSize := fyne.NewSize(
+			lerp32(t.fromSize.Width, t.toSize.Width, eased),
+			lerp32(t.fromSize.Height, t.toSize.Height, eased),
+		)
+		winPos := fyne.NewPos(
+			lerp32(t.fromPos.X, t.toPos.X, eased),
+			lerp32(t.fromPos.Y, t.toPos.Y, eased),
+		)
+		fyne.Do(func() {
+			if !p.isPlaybackActive(playbackID) {
+				return
+			}
+			p.Canvas.Image = frame
+			p.Canvas.Resize(winSize)
+			p.window.Resize(winSize)
+			if t.canMove {
+				platform.MoveWindowTo(p.window, winPos.X, winPos.Y)
+			}
+			p.Canvas.Refresh()
+		})
+		if !sleepContext(ctx, transitionFrameInterval) {
+			return true
+		}
+	}
+
+	fyne.Do(func() {
+		if !p.isPlaybackActive(playbackID) {
+			return
+		}
+		p.Canvas.Image = to
+		p.applyScaledSize()
+		if t.canMove {
+			platform.MoveWindowTo(p.window, t.toPos.X, t.toPos.Y)
+		}
+		p.Canvas.Refresh()
+	})
+	return true
+}
+
+// composeTransition 合成过渡中的一帧，progress 取值 0~1。
+func composeTransition(kind TransitionKind, from, to image.Image, progress float64) image.Image {
+	bounds := image.Rect(0, 0, to.Bounds().Dx(), to.Bounds().Dy())
+	dst := image.NewRGBA(bounds)
+
+	switch kind {
+	case TransitionSlide:
+		// 新图从右边推入，旧图向左移出
+		offset := int(float64(bounds.Dx()) * progress)
+		draw.Draw(dst, bounds, from, image.Pt(offset, 0), draw.Src)
+		draw.Draw(dst, bounds.Add(image.Pt(bounds.Dx()-offset, 0)), to, to.Bounds().Min, draw.Src)
+	case TransitionZoom:
+		draw.Draw(dst, bounds, from, from.Bounds().Min, draw.Src)
+		scale := transitionZoomStart + (1-transitionZoomStart)*progress
+		w := int(float64(bounds.Dx()) * scale)
+		h := int(float64(bounds.Dy()) * scale)
+		rect := image.Rect(0, 0, w, h).Add(image.Pt((bounds.Dx()-w)/2, (bounds.Dy()-h)/2))
+		xdraw.ApproxBiLinear.Scale(dst, rect, to, to.Bounds(), xdraw.Over, &xdraw.Options{
+			SrcMask: image.NewUniform(color.Alpha{A: uint8(progress * 255)}),
+		})
+	default:
+		crossfade(dst, from, to, progress)
+	}
+	return dst
+}
+
+// crossfade 按预乘的颜色线性混合：旧图占 1-progress，新图占 progress。
+// 透明贴纸的旧图也会逐渐淡出，不会在过渡结束时突然消失。
+func crossfade(dst *image.RGBA, from, to image.Image, progress float64) {
+	bounds := dst.Bounds()
+	src := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, from, from.Bounds().Min, draw.Src)
+	draw.Draw(src, bounds, to, to.Bounds().Min, draw.Src)
+	for i, v := range src.Pix {
+		dst.Pix[i] = uint8(float64(dst.Pix[i])*(1-progress) + float64(v)*progress + 0.5)
+	}
+}
+
+// stretchImage 把旧画面拉伸到新画面的尺寸，过渡时窗口尺寸也在变，拉伸看起来是连续的。
+func stretchImage(src image.Image, size image.Point) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+func easeInOut(t float64) float64 {
+	t = min(max(t, 0), 1)
+	return t * t * (3 - 2*t)
+}
+
+func lerp32(a, b float32, t float64) float32 {
+	return a + (b-a)*float32(t)
+}
@@ -0,0 +1,153 @@
+package player
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func solidRGBA(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestComposeTransition(t *testing.T) {
+	t.Parallel()
+
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	from := solidRGBA(10, 4, red)
+	to := solidRGBA(10, 4, blue)
+
+	fade := composeTransition(TransitionCrossfade, from, to, 0.5)
+	r, _, b, _ := fade.At(5, 2).RGBA()
+	if r>>8 < 100 || r>>8 > 155 || b>>8 < 100 || b>>8 > 155 {
+		t.Fatalf("crossfade midpoint = r%d b%d, want roughly half of each", r>>8, b>>8)
+	}
+
+	// 透明的旧图也要跟着淡出
+	transparent := solidRGBA(10, 4, color.RGBA{})
+	if _, _, _, a := composeTransition(TransitionCrossfade, from, transparent, 0.75).At(5, 2).RGBA(); a>>8 < 60 || a>>8 > 68 {
+		t.Fatalf("fading out over transparent = alpha %d, want about a quarter", a>>8)
+	}
+	if _, _, _, a := composeTransition(TransitionCrossfade, transparent, to, 0.25).At(5, 2).RGBA(); a>>8 < 60 || a>>8 > 68 {
+		t.Fatalf("fading in from transparent = alpha %d, want about a quarter", a>>8)
+	}
+
+	slide := composeTransition(TransitionSlide, from, to, 0.5)
+	assertRGBA(t, slide.At(2, 2), red)
+	assertRGBA(t, slide.At(7, 2), blue)
+
+	zoom := composeTransition(TransitionZoom, from, to, 0)
+	assertRGBA(t, zoom.At(0, 0), red)
+	if end := composeTransition(TransitionZoom, from, to, 1); end.Bounds() != to.Bounds() {
+		t.Fatalf("zoom end bounds = %v, want %v", end.Bounds(), to.Bounds())
+	}
+	assertRGBA(t, composeTransition(TransitionZoom, from, to, 1).At(5, 2), blue)
+}
+
+func TestEaseInOut(t *testing.T) {
+	t.Parallel()
+
+	if easeInOut(0) != 0 || easeInOut(1) != 1 || easeInOut(0.5) != 0.5 {
+		t.Fatalf("easeInOut should map 0, 0.5, 1 to themselves")
+	}
+	if easeInOut(-1) != 0 || easeInOut(2) != 1 {
+		t.Fatalf("easeInOut should clamp to [0,1]")
+	}
+}
+
+func TestSetTransition_ClampsDuration(t *testing.T) {
+	t.Parallel()
+
+	p := &Player{}
+	p.SetTransition(TransitionSettings{Kind: TransitionSlide, Duration: time.Hour})
+	if got := p.Transition(); got.Kind != TransitionSlide || got.Duration != MaxTransitionDuration {
+		t.Fatalf("Transition() = %+v", got)
+	}
+}
+
+func TestShowStillImage_TransitionsToNewImage(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	p := NewPlayer(a, w)
+	p.SetTransition(TransitionSettings{Kind: TransitionCrossfade, Duration: 60 * time.Millisecond})
+
+	first := p.beginPlayback()
+	showStillImage(p, solidRGBA(100, 100, color.RGBA{255, 0, 0, 255}), first)
+	fyne.DoAndWait(func() {})
+	if p.hasPendingTransition(first) {
+		t.Fatalf("first image has nothing to transition from")
+	}
+
+	second := p.beginPlayback()
+	target := solidRGBA(100, 50, color.RGBA{0, 0, 255, 255})
+	showStillImage(p, target, second)
+	if !p.hasPendingTransition(second) && p.running.Load() == 0 {
+		t.Fatalf("second image should start a transition")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var done bool
+		fyne.DoAndWait(func() {
+			done = p.running.Load() == 0 && p.Canvas.Size().Height == p.scaledSizeForZoom(p.zoom).Height
+		})
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("transition did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assertRGBA(t, p.Canvas.Image.At(10, 10), color.RGBA{0, 0, 255, 255})
+	p.Close()
+}
+
+func TestPlay_SamePathSkipsTransition(t *testing.T) {
+	a := fynetest.NewApp()
+	defer a.Quit()
+	w := a.NewWindow("test")
+	defer w.Close()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solidRGBA(4, 4, color.RGBA{255, 0, 0, 255})); err != nil {
+		t.Fatal(err)
+	}
+	first := writeTestFile(t, "first.png", buf.Bytes())
+	second := writeTestFile(t, "second.png", buf.Bytes())
+
+	p := NewPlayer(a, w)
+	defer p.Close()
+	p.SetTransition(TransitionSettings{Kind: TransitionCrossfade, Duration: time.Second})
+	p.Play(first)
+	fyne.DoAndWait(func() {})
+
+	// 改了单图设置后重新播放同一张图，直接切换
+	p.Play(first)
+	fyne.DoAndWait(func() {})
+	if p.hasPendingTransition(p.playbackID.Load()) || p.running.Load() != 0 {
+		t.Fatalf("replaying the same image should not transition")
+	}
+
+	p.Play(second)
+	fyne.DoAndWait(func() {})
+	if !p.hasPendingTransition(p.playbackID.Load()) && p.running.Load() == 0 {
+		t.Fatalf("switching to another image should transition")
+	}
+}
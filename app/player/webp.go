@@ -1,37 +1,245 @@
 package player
 
 import (
-	"log"
-	"os"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"time"
 
-	"fyne.io/fyne/v2"
 	"golang.org/x/image/webp"
 )
 
-func PlayWebP(p *Player, path string, playbackID uint64) {
-	f, err := os.Open(path)
+const (
+	webpFlagAnimation = 1 << 1
+	webpFlagAlpha     = 1 << 4
+
+	// ANMF 帧标志位
+	webpFrameDisposeBackground = 1 << 0
+	webpFrameNoBlend           = 1 << 1
+)
+
+var errInvalidAnimatedWebP = errors.New("webp: invalid animated webp")
+
+type webpDecoder struct{}
+
+func (webpDecoder) Name() string { return "webp" }
+
+func (webpDecoder) Extensions() []string { return []string{"webp"} }
+
+func (webpDecoder) Sniff(header []byte) bool {
+	return len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WEBP"
+}
+
+func (webpDecoder) DecodeConfig(r io.Reader) (image.Config, error) {
+	return webp.DecodeConfig(r)
+}
+
+func (webpDecoder) DecodeStill(r io.Reader) (image.Image, error) {
+	return webp.Decode(r)
+}
+
+func (webpDecoder) DecodeAnimation(r io.Reader) (*Animation, error) {
+	data, err := io.ReadAll(r)
 	if err != nil {
-		log.Printf("open webp failed: %v", err)
-		return
+		return nil, err
 	}
-	defer f.Close()
+	if !isAnimatedWebP(data) {
+		return nil, nil
+	}
+	return decodeAnimatedWebP(data)
+}
+
+type webpChunk struct {
+	id   string
+	data []byte
+}
 
-	img, err := webp.Decode(f)
+// readWebPChunks 解析 RIFF 容器，返回 WEBP 下的所有顶层 chunk。
+func readWebPChunks(data []byte) ([]webpChunk, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidAnimatedWebP
+	}
+	riffSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := 8 + riffSize
+	if end > len(data) || end < 12 {
+		end = len(data)
+	}
+	return splitWebPChunks(data[12:end])
+}
+
+func splitWebPChunks(data []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	for len(data) >= 8 {
+		id := string(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size < 0 || 8+size > len(data) {
+			return nil, errInvalidAnimatedWebP
+		}
+		chunks = append(chunks, webpChunk{id: id, data: data[8 : 8+size]})
+		next := 8 + size + size&1
+		if next > len(data) {
+			break
+		}
+		data = data[next:]
+	}
+	return chunks, nil
+}
+
+func isAnimatedWebP(data []byte) bool {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return false
+	}
+	if string(data[12:16]) != "VP8X" {
+		return false
+	}
+	return data[20]&webpFlagAnimation != 0
+}
+
+func decodeAnimatedWebP(data []byte) (*Animation, error) {
+	chunks, err := readWebPChunks(data)
 	if err != nil {
-		log.Printf("decode webp failed: %v", err)
-		return
+		return nil, err
+	}
+
+	var (
+		width, height int
+		loopCount     int
+		seenVP8X      bool
+	)
+	frameChunks := make([][]byte, 0, len(chunks))
+	for _, c := range chunks {
+		switch c.id {
+		case "VP8X":
+			if len(c.data) < 10 {
+				return nil, errInvalidAnimatedWebP
+			}
+			seenVP8X = true
+			width = int(readUint24(c.data[4:7])) + 1
+			height = int(readUint24(c.data[7:10])) + 1
+		case "ANIM":
+			if len(c.data) < 6 {
+				return nil, errInvalidAnimatedWebP
+			}
+			loopCount = int(binary.LittleEndian.Uint16(c.data[4:6]))
+		case "ANMF":
+			frameChunks = append(frameChunks, c.data)
+		}
 	}
-	if !p.isPlaybackActive(playbackID) {
-		return
+	if !seenVP8X || width <= 0 || height <= 0 || len(frameChunks) == 0 {
+		return nil, errInvalidAnimatedWebP
 	}
 
-	fyne.Do(func() {
-		if !p.isPlaybackActive(playbackID) {
-			return
+	bounds := image.Rect(0, 0, width, height)
+	canvas := image.NewRGBA(bounds)
+	transparent := image.NewUniform(color.Transparent)
+
+	anim := &Animation{
+		Width:     width,
+		Height:    height,
+		Frames:    make([]image.Image, 0, len(frameChunks)),
+		Delays:    make([]time.Duration, 0, len(frameChunks)),
+		LoopCount: loopCount,
+	}
+
+	var disposeRect image.Rectangle
+	for _, raw := range frameChunks {
+		if len(raw) < 16 {
+			return nil, errInvalidAnimatedWebP
+		}
+		x := int(readUint24(raw[0:3])) * 2
+		y := int(readUint24(raw[3:6])) * 2
+		w := int(readUint24(raw[6:9])) + 1
+		h := int(readUint24(raw[9:12])) + 1
+		duration := time.Duration(readUint24(raw[12:15])) * time.Millisecond
+		flags := raw[15]
+
+		if !disposeRect.Empty() {
+			draw.Draw(canvas, disposeRect, transparent, image.Point{}, draw.Src)
+			disposeRect = image.Rectangle{}
+		}
+
+		frame, err := decodeWebPFrame(raw[16:], w, h)
+		if err != nil {
+			return nil, err
+		}
+
+		rect := image.Rect(x, y, x+w, y+h).Intersect(bounds)
+		op := draw.Over
+		if flags&webpFrameNoBlend != 0 {
+			op = draw.Src
 		}
-		b := img.Bounds()
-		p.updateBaseSize(b.Dx(), b.Dy())
-		p.Canvas.Image = img
-		p.Canvas.Refresh()
-	})
+		draw.Draw(canvas, rect, frame, frame.Bounds().Min, op)
+
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas, bounds))
+		anim.Delays = append(anim.Delays, normalizeFrameDelay(duration))
+
+		if flags&webpFrameDisposeBackground != 0 {
+			disposeRect = rect
+		}
+	}
+
+	return anim, nil
+}
+
+// decodeWebPFrame 把 ANMF 帧数据重新包装成独立的 WebP 文件，交给 x/image/webp 解码。
+func decodeWebPFrame(data []byte, width, height int) (image.Image, error) {
+	chunks, err := splitWebPChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var alph, bitstream *webpChunk
+	for i := range chunks {
+		switch chunks[i].id {
+		case "ALPH":
+			alph = &chunks[i]
+		case "VP8 ", "VP8L":
+			bitstream = &chunks[i]
+		}
+	}
+	if bitstream == nil {
+		return nil, errInvalidAnimatedWebP
+	}
+
+	var body bytes.Buffer
+	if alph != nil && bitstream.id == "VP8 " {
+		vp8x := make([]byte, 10)
+		vp8x[0] = webpFlagAlpha
+		putUint24(vp8x[4:7], uint32(width-1))
+		putUint24(vp8x[7:10], uint32(height-1))
+		writeWebPChunk(&body, "VP8X", vp8x)
+		writeWebPChunk(&body, alph.id, alph.data)
+	}
+	writeWebPChunk(&body, bitstream.id, bitstream.data)
+
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	_ = binary.Write(&file, binary.LittleEndian, uint32(4+body.Len()))
+	file.WriteString("WEBP")
+	file.Write(body.Bytes())
+
+	return webp.Decode(bytes.NewReader(file.Bytes()))
+}
+
+func writeWebPChunk(buf *bytes.Buffer, id string, data []byte) {
+	buf.WriteString(id)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)&1 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func readUint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
 }
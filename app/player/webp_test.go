@@ -0,0 +1,171 @@
+package player
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// encodeSolidVP8L 生成一张纯色的 VP8L 无损位流：每个 Huffman 码表只有一个符号，像素数据不占位。
+func encodeSolidVP8L(width, height int, c color.NRGBA) []byte {
+	var bits uint64
+	var nbits uint
+	var out []byte
+	write := func(v uint64, n uint) {
+		bits |= v << nbits
+		nbits += n
+		for nbits >= 8 {
+			out = append(out, byte(bits))
+			bits >>= 8
+			nbits -= 8
+		}
+	}
+
+	out = append(out, 0x2f)
+	write(uint64(width-1), 14)
+	write(uint64(height-1), 14)
+	write(1, 1) // alpha_is_used
+	write(0, 3) // version
+	write(0, 1) // no transform
+	write(0, 1) // no color cache
+	write(0, 1) // no meta prefix codes
+
+	simpleCode := func(symbol uint8) {
+		write(1, 1) // simple code
+		write(0, 1) // one symbol
+		write(1, 1) // 8-bit symbol
+		write(uint64(symbol), 8)
+	}
+	simpleCode(c.G)
+	simpleCode(c.R)
+	simpleCode(c.B)
+	simpleCode(c.A)
+	simpleCode(0) // distance
+
+	if nbits > 0 {
+		out = append(out, byte(bits))
+	}
+	return out
+}
+
+type testWebPFrame struct {
+	x, y, w, h int
+	duration   time.Duration
+	flags      byte
+	color      color.NRGBA
+}
+
+func buildAnimatedWebP(width, height, loopCount int, frames []testWebPFrame) []byte {
+	var body bytes.Buffer
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation | webpFlagAlpha
+	putUint24(vp8x[4:7], uint32(width-1))
+	putUint24(vp8x[7:10], uint32(height-1))
+	writeWebPChunk(&body, "VP8X", vp8x)
+
+	anim := make([]byte, 6)
+	binary.LittleEndian.PutUint16(anim[4:6], uint16(loopCount))
+	writeWebPChunk(&body, "ANIM", anim)
+
+	for _, f := range frames {
+		var frame bytes.Buffer
+		header := make([]byte, 16)
+		putUint24(header[0:3], uint32(f.x/2))
+		putUint24(header[3:6], uint32(f.y/2))
+		putUint24(header[6:9], uint32(f.w-1))
+		putUint24(header[9:12], uint32(f.h-1))
+		putUint24(header[12:15], uint32(f.duration/time.Millisecond))
+		header[15] = f.flags
+		frame.Write(header)
+		writeWebPChunk(&frame, "VP8L", encodeSolidVP8L(f.w, f.h, f.color))
+		writeWebPChunk(&body, "ANMF", frame.Bytes())
+	}
+
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	_ = binary.Write(&file, binary.LittleEndian, uint32(4+body.Len()))
+	file.WriteString("WEBP")
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+func TestIsAnimatedWebP(t *testing.T) {
+	t.Parallel()
+
+	data := buildAnimatedWebP(2, 2, 0, []testWebPFrame{
+		{w: 2, h: 2, duration: 100 * time.Millisecond, color: color.NRGBA{R: 255, A: 255}},
+	})
+	if !isAnimatedWebP(data) {
+		t.Fatalf("animated webp should be detected")
+	}
+	if isAnimatedWebP([]byte("RIFF\x00\x00\x00\x00WEBPVP8L")) {
+		t.Fatalf("short still webp should not be detected as animated")
+	}
+	if isAnimatedWebP(nil) {
+		t.Fatalf("nil data should not be detected as animated")
+	}
+}
+
+func TestDecodeAnimatedWebP_BlendAndDispose(t *testing.T) {
+	t.Parallel()
+
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	green := color.NRGBA{G: 255, A: 255}
+
+	data := buildAnimatedWebP(4, 2, 3, []testWebPFrame{
+		{x: 0, y: 0, w: 4, h: 2, duration: 100 * time.Millisecond, color: red},
+		{x: 2, y: 0, w: 2, h: 2, duration: 50 * time.Millisecond, flags: webpFrameDisposeBackground, color: blue},
+		{x: 0, y: 0, w: 2, h: 2, duration: 0, flags: webpFrameNoBlend, color: color.NRGBA{}},
+		{x: 0, y: 0, w: 2, h: 1, duration: 20 * time.Millisecond, color: green},
+	})
+
+	anim, err := decodeAnimatedWebP(data)
+	if err != nil {
+		t.Fatalf("decodeAnimatedWebP: %v", err)
+	}
+	if anim.Width != 4 || anim.Height != 2 {
+		t.Fatalf("size = %dx%d, want 4x2", anim.Width, anim.Height)
+	}
+	if anim.LoopCount != 3 {
+		t.Fatalf("loopCount = %d, want 3", anim.LoopCount)
+	}
+	if len(anim.Frames) != 4 {
+		t.Fatalf("len(frames) = %d, want 4", len(anim.Frames))
+	}
+
+	assertRGBA(t, anim.Frames[0].At(3, 1), color.RGBA{255, 0, 0, 255})
+
+	assertRGBA(t, anim.Frames[1].At(0, 0), color.RGBA{255, 0, 0, 255})
+	assertRGBA(t, anim.Frames[1].At(3, 0), color.RGBA{0, 0, 255, 255})
+
+	// 上一帧 dispose 到背景，本帧不混合直接覆盖为透明
+	assertRGBA(t, anim.Frames[2].At(3, 0), color.RGBA{0, 0, 0, 0})
+	assertRGBA(t, anim.Frames[2].At(0, 0), color.RGBA{0, 0, 0, 0})
+
+	assertRGBA(t, anim.Frames[3].At(0, 0), color.RGBA{0, 255, 0, 255})
+	assertRGBA(t, anim.Frames[3].At(0, 1), color.RGBA{0, 0, 0, 0})
+
+	if got := anim.delayAt(0); got != 100*time.Millisecond {
+		t.Fatalf("delay[0] = %v, want 100ms", got)
+	}
+	if got := anim.delayAt(2); got != minFrameDelay {
+		t.Fatalf("delay[2] = %v, want %v", got, minFrameDelay)
+	}
+}
+
+func TestDecodeAnimatedWebP_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := decodeAnimatedWebP([]byte("not a webp")); err == nil {
+		t.Fatalf("invalid data should fail")
+	}
+
+	noFrames := buildAnimatedWebP(2, 2, 0, nil)
+	if _, err := decodeAnimatedWebP(noFrames); err == nil {
+		t.Fatalf("animated webp without frames should fail")
+	}
+}
@@ -0,0 +1,544 @@
+// Package remote 从网络图片列表下载图片到本地缓存。列表地址返回纯文本，每行一个图片地址。
+package remote
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	manifestName = "manifest.json"
+
+	DefaultMaxImageBytes   = 50 << 20
+	DefaultMaxCacheBytes   = 500 << 20
+	DefaultRefreshInterval = 24 * time.Hour
+
+	// 列表本身不应该很大，超过就当作地址填错了
+	maxListBytes = 4 << 20
+	// 选中的图下载失败时，最多再换几张试
+	maxPickAttempts = 5
+)
+
+var (
+	ErrEmptyList     = errors.New("remote: image list is empty")
+	ErrNoImage       = errors.New("remote: no image available")
+	ErrImageTooLarge = errors.New("remote: image exceeds size limit")
+)
+
+type Options struct {
+	Client *http.Client
+	// MaxImageBytes 是单张图片的大小上限，MaxCacheBytes 是缓存目录的总大小上限
+	MaxImageBytes int64
+	MaxCacheBytes int64
+	// RefreshInterval 是自动更新列表的间隔
+	RefreshInterval time.Duration
+	// Validate 检查下载的文件能否显示，返回 false 时丢弃
+	Validate func(path string) bool
+	Now      func() time.Time
+}
+
+// Entry 是列表中的一张图。
+type Entry struct {
+	URL string `json:"url"`
+	// File 是缓存文件名，为空表示还没下载或已被清理
+	File         string    `json:"file,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Size         int64     `json:"size,omitempty"`
+	Shown        bool      `json:"shown,omitempty"`
+	LastUsed     time.Time `json:"last_used,omitempty"`
+}
+
+type manifest struct {
+	ListURL          string    `json:"list_url"`
+	ListETag         string    `json:"list_etag,omitempty"`
+	ListLastModified string    `json:"list_last_modified,omitempty"`
+	Fetched          time.Time `json:"fetched,omitempty"`
+	Entries          []*Entry  `json:"entries"`
+}
+
+// Source 是一个网络图片列表和它的本地缓存，方法可以并发调用。
+type Source struct {
+	listURL string
+	dir     string
+	opts    Options
+
+	// refreshMu 让同时触发的更新排队，避免合并列表时替换掉正在下载的记录
+	refreshMu sync.Mutex
+	mu        sync.Mutex
+	manifest  manifest
+}
+
+// Open 打开 dir 中的缓存，列表地址变了时丢弃旧缓存的记录。
+func Open(listURL, dir string, opts Options) (*Source, error) {
+	listURL = strings.TrimSpace(listURL)
+	if err := ValidateURL(listURL); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: time.Minute}
+	}
+	if opts.MaxImageBytes <= 0 {
+		opts.MaxImageBytes = DefaultMaxImageBytes
+	}
+	if opts.MaxCacheBytes <= 0 {
+		opts.MaxCacheBytes = DefaultMaxCacheBytes
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = DefaultRefreshInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	s := &Source{listURL: listURL, dir: dir, opts: opts}
+	s.manifest = manifest{ListURL: listURL}
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	switch {
+	case err == nil:
+		var m manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			log.Printf("load remote manifest failed: %v", err)
+		} else if m.ListURL == listURL {
+			s.manifest = m
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidateURL 检查是否为 http 或 https 地址。
+func ValidateURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("remote: %q is not an http(s) url", raw)
+	}
+	return nil
+}
+
+func (s *Source) ListURL() string {
+	return s.listURL
+}
+
+// NeedsRefresh 判断距离上次更新列表是否超过了更新间隔。
+func (s *Source) NeedsRefresh() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.manifest.Fetched.IsZero() || s.opts.Now().Sub(s.manifest.Fetched) >= s.opts.RefreshInterval
+}
+
+// Entries 返回列表中所有图片的副本，按列表顺序。
+func (s *Source) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Entry, 0, len(s.manifest.Entries))
+	for _, e := range s.manifest.Entries {
+		out = append(out, *e)
+	}
+	return out
+}
+
+// Refresh 重新获取列表并下载新加入的图片，已缓存的图片按 ETag/Last-Modified 重新验证。
+// 返回本次新加入列表的图片数量。
+func (s *Source) Refresh(ctx context.Context) (int, error) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	s.mu.Lock()
+	etag, modified := s.manifest.ListETag, s.manifest.ListLastModified
+	s.mu.Unlock()
+
+	urls, etag, modified, changed, err := s.fetchList(ctx, etag, modified)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	added := 0
+	if changed {
+		added = s.mergeLocked(urls)
+		s.manifest.ListETag, s.manifest.ListLastModified = etag, modified
+	}
+	s.manifest.Fetched = s.opts.Now()
+	entries := append([]*Entry(nil), s.manifest.Entries...)
+	s.mu.Unlock()
+
+	prefetch := true
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return added, err
+		}
+		s.mu.Lock()
+		cached, shown := s.cachedLocked(e), e.Shown
+		s.mu.Unlock()
+		// 已缓存的重新验证；没显示过的图在缓存上限内提前下载，其余的等选中时再下载
+		if !cached && (shown || !prefetch) {
+			continue
+		}
+		if err := s.download(ctx, e); err != nil {
+			log.Printf("download remote image %q failed: %v", e.URL, err)
+			continue
+		}
+		if cached {
+			continue
+		}
+		s.mu.Lock()
+		if s.freshBytesLocked() > s.opts.MaxCacheBytes {
+			// 超出上限的这张不留，否则清理时会删掉刚下载的新图
+			s.removeFileLocked(e)
+			prefetch = false
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictLocked("")
+	return added, s.saveLocked()
+}
+
+func (s *Source) fetchList(ctx context.Context, etag, modified string) (urls []string, newETag, newModified string, changed bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.listURL, nil)
+	if err != nil {
+		return nil, "", "", false, err
+	}
+	setValidators(req, etag, modified)
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, "", "", false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, modified, false, nil
+	case http.StatusOK:
+	default:
+		return nil, "", "", false, fmt.Errorf("remote: fetch list: %s", resp.Status)
+	}
+
+	base := resp.Request.URL
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxListBytes))
+	seen := make(map[string]bool)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// 相对地址按列表地址解析
+		ref, err := url.Parse(line)
+		if err != nil {
+			continue
+		}
+		abs := base.ResolveReference(ref).String()
+		if ValidateURL(abs) != nil || seen[abs] {
+			continue
+		}
+		seen[abs] = true
+		urls = append(urls, abs)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", "", false, err
+	}
+	if len(urls) == 0 {
+		return nil, "", "", false, ErrEmptyList
+	}
+	return urls, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), true, nil
+}
+
+// mergeLocked 按新列表更新记录：保留已有图片的缓存和显示状态，删掉不在列表里的图。
+func (s *Source) mergeLocked(urls []string) int {
+	old := make(map[string]*Entry, len(s.manifest.Entries))
+	for _, e := range s.manifest.Entries {
+		old[e.URL] = e
+	}
+	entries := make([]*Entry, 0, len(urls))
+	added := 0
+	for _, u := range urls {
+		if e, ok := old[u]; ok {
+			entries = append(entries, e)
+			delete(old, u)
+			continue
+		}
+		entries = append(entries, &Entry{URL: u})
+		added++
+	}
+	for _, e := range old {
+		s.removeFileLocked(e)
+	}
+	s.manifest.Entries = entries
+	return added
+}
+
+// freshBytesLocked 是已缓存但还没显示过的图的总大小，显示过的图可以随时清理给它们腾位置。
+func (s *Source) freshBytesLocked() int64 {
+	var total int64
+	for _, e := range s.manifest.Entries {
+		if e.File != "" && !e.Shown {
+			total += e.Size
+		}
+	}
+	return total
+}
+
+func (s *Source) cachedLocked(e *Entry) bool {
+	if e.File == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(s.dir, e.File))
+	return err == nil
+}
+
+func (s *Source) removeFileLocked(e *Entry) {
+	if e.File == "" {
+		return
+	}
+	if err := os.Remove(filepath.Join(s.dir, e.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("remove cached image failed: %v", err)
+	}
+	e.File, e.ETag, e.LastModified, e.Size = "", "", "", 0
+}
+
+// cacheFileName 用地址的哈希作文件名，保留原来的扩展名方便识别。
+func cacheFileName(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	name := hex.EncodeToString(sum[:12])
+	if u, err := url.Parse(rawURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); len(ext) > 1 && len(ext) <= 5 {
+			name += ext
+		}
+	}
+	return name
+}
+
+func setValidators(req *http.Request, etag, modified string) {
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if modified != "" {
+		req.Header.Set("If-Modified-Since", modified)
+	}
+}
+
+// download 下载或重新验证一张图，服务器返回 304 时保留缓存。
+func (s *Source) download(ctx context.Context, e *Entry) error {
+	s.mu.Lock()
+	cached := s.cachedLocked(e)
+	etag, modified := "", ""
+	if cached {
+		etag, modified = e.ETag, e.LastModified
+	}
+	s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.URL, nil)
+	if err != nil {
+		return err
+	}
+	setValidators(req, etag, modified)
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cached {
+			return nil
+		}
+		return fmt.Errorf("remote: unexpected 304 for %s", e.URL)
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("remote: download: %s", resp.Status)
+	}
+	if resp.ContentLength > s.opts.MaxImageBytes {
+		return ErrImageTooLarge
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "download-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, s.opts.MaxImageBytes+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n > s.opts.MaxImageBytes {
+		return ErrImageTooLarge
+	}
+	if s.opts.Validate != nil && !s.opts.Validate(tmpName) {
+		return fmt.Errorf("remote: %s is not a supported image", e.URL)
+	}
+
+	name := cacheFileName(e.URL)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 下载期间列表可能已更新，这张图不在列表里了就不留缓存
+	if !s.trackedLocked(e) {
+		return fmt.Errorf("remote: %s was removed from the list", e.URL)
+	}
+	if err := os.Rename(tmpName, filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+	e.File = name
+	e.ETag = resp.Header.Get("ETag")
+	e.LastModified = resp.Header.Get("Last-Modified")
+	e.Size = n
+	return nil
+}
+
+func (s *Source) trackedLocked(e *Entry) bool {
+	for _, cur := range s.manifest.Entries {
+		if cur == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Next 选出下一张要显示的图并返回缓存文件路径。列表里有没显示过的图时先从它们里随机，
+// 全部显示过后再从全部图片里随机，并避开上一张。没缓存的图现场下载，下载失败就换一张。
+func (s *Source) Next(ctx context.Context, last string, randIntn func(int) int) (string, error) {
+	tried := make(map[*Entry]bool)
+	for attempt := 0; attempt < maxPickAttempts; attempt++ {
+		s.mu.Lock()
+		e := s.pickLocked(last, tried, randIntn)
+		s.mu.Unlock()
+		if e == nil {
+			break
+		}
+		tried[e] = true
+
+		s.mu.Lock()
+		cached := s.cachedLocked(e)
+		s.mu.Unlock()
+		if !cached {
+			if err := s.download(ctx, e); err != nil {
+				log.Printf("download remote image %q failed: %v", e.URL, err)
+				continue
+			}
+		}
+
+		s.mu.Lock()
+		e.Shown = true
+		e.LastUsed = s.opts.Now()
+		s.evictLocked(e.File)
+		err := s.saveLocked()
+		p := filepath.Join(s.dir, e.File)
+		s.mu.Unlock()
+		if err != nil {
+			log.Printf("save remote manifest failed: %v", err)
+		}
+		return p, nil
+	}
+	return "", ErrNoImage
+}
+
+func (s *Source) pickLocked(last string, tried map[*Entry]bool, randIntn func(int) int) *Entry {
+	var fresh, all []*Entry
+	for _, e := range s.manifest.Entries {
+		if tried[e] {
+			continue
+		}
+		all = append(all, e)
+		if !e.Shown {
+			fresh = append(fresh, e)
+		}
+	}
+	pool := fresh
+	if len(pool) == 0 {
+		pool = all
+		// 只有一张时允许重复
+		if len(pool) > 1 {
+			filtered := pool[:0:0]
+			for _, e := range pool {
+				if e.File == "" || filepath.Join(s.dir, e.File) != last {
+					filtered = append(filtered, e)
+				}
+			}
+			pool = filtered
+		}
+	}
+	if len(pool) == 0 {
+		return nil
+	}
+	i := 0
+	if randIntn != nil {
+		if n := randIntn(len(pool)); n >= 0 && n < len(pool) {
+			i = n
+		}
+	}
+	return pool[i]
+}
+
+// evictLocked 缓存超过上限时，从最久没用的图开始删，keep 是正要显示的图，不删。
+func (s *Source) evictLocked(keep string) {
+	var total int64
+	var cached []*Entry
+	for _, e := range s.manifest.Entries {
+		if e.File == "" {
+			continue
+		}
+		total += e.Size
+		if e.File != keep {
+			cached = append(cached, e)
+		}
+	}
+	if total <= s.opts.MaxCacheBytes {
+		return
+	}
+	// 没显示过的图还要优先显示，最后才删
+	sort.SliceStable(cached, func(i, j int) bool {
+		if cached[i].Shown != cached[j].Shown {
+			return cached[i].Shown
+		}
+		return cached[i].LastUsed.Before(cached[j].LastUsed)
+	})
+	for _, e := range cached {
+		if total <= s.opts.MaxCacheBytes {
+			break
+		}
+		total -= e.Size
+		s.removeFileLocked(e)
+	}
+}
+
+func (s *Source) saveLocked() error {
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir, manifestName+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, manifestName))
+}
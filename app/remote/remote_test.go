@@ -0,0 +1,413 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer 提供一个图片列表和若干图片，支持 ETag 重新验证，并记录每个地址的请求和 304 次数。
+type testServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	list     []string
+	images   map[string]string
+	requests map[string]int
+	notMod   map[string]int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	s := &testServer{images: make(map[string]string), requests: make(map[string]int), notMod: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.URL.Path]++
+
+	var body string
+	if r.URL.Path == "/list.txt" {
+		body = strings.Join(s.list, "\n")
+	} else {
+		data, ok := s.images[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body = data
+	}
+	etag := fmt.Sprintf("%q", fmt.Sprintf("%x", len(body))+body[:min(len(body), 8)])
+	if r.Header.Get("If-None-Match") == etag {
+		s.notMod[r.URL.Path]++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	fmt.Fprint(w, body)
+}
+
+func (s *testServer) setList(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list = lines
+}
+
+func (s *testServer) setImage(path, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images[path] = data
+}
+
+func (s *testServer) counts(path string) (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path], s.notMod[path]
+}
+
+func TestValidateURL(t *testing.T) {
+	t.Parallel()
+
+	for _, raw := range []string{"https://example.com/list.txt", " http://a.b/c "} {
+		if err := ValidateURL(raw); err != nil {
+			t.Fatalf("ValidateURL(%q): %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"", "ftp://example.com/x", "/local/path", "http://"} {
+		if err := ValidateURL(raw); err == nil {
+			t.Fatalf("ValidateURL(%q) should fail", raw)
+		}
+	}
+}
+
+func TestRefresh_DownloadsAndRevalidates(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.setImage("/a.png", "image-a")
+	srv.setImage("/b.png", "image-b")
+	srv.setList("# comment", "/a.png", "", srv.URL+"/b.png", "/a.png", "not a url ::")
+
+	dir := t.TempDir()
+	src, err := Open(srv.URL+"/list.txt", dir, Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !src.NeedsRefresh() {
+		t.Fatalf("a new source should need a refresh")
+	}
+	added, err := src.Refresh(context.Background())
+	if err != nil || added != 2 {
+		t.Fatalf("Refresh = %d, %v, want 2 new images", added, err)
+	}
+	for _, e := range src.Entries() {
+		data, err := os.ReadFile(filepath.Join(dir, e.File))
+		if err != nil || !strings.HasPrefix(string(data), "image-") {
+			t.Fatalf("cached %s = %q, %v", e.URL, data, err)
+		}
+	}
+	if src.NeedsRefresh() {
+		t.Fatalf("a fresh source should not need a refresh")
+	}
+
+	// 第二次更新时列表和图片都返回 304
+	if added, err := src.Refresh(context.Background()); err != nil || added != 0 {
+		t.Fatalf("second Refresh = %d, %v", added, err)
+	}
+	if _, notMod := srv.counts("/list.txt"); notMod != 1 {
+		t.Fatalf("list should be revalidated with ETag, got %d 304s", notMod)
+	}
+	if reqs, notMod := srv.counts("/a.png"); reqs != 2 || notMod != 1 {
+		t.Fatalf("image a: %d requests, %d 304s", reqs, notMod)
+	}
+
+	// 列表里去掉的图片连缓存一起删掉
+	srv.setList("/b.png")
+	removedFile := src.Entries()[0].File
+	if _, err := src.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if len(src.Entries()) != 1 {
+		t.Fatalf("entries = %d, want 1", len(src.Entries()))
+	}
+	if _, err := os.Stat(filepath.Join(dir, removedFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("removed image should be deleted from the cache")
+	}
+}
+
+func TestRefresh_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.setList("# nothing")
+	src, err := Open(srv.URL+"/list.txt", t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := src.Refresh(context.Background()); !errors.Is(err, ErrEmptyList) {
+		t.Fatalf("empty list error = %v", err)
+	}
+	missing, err := Open(srv.URL+"/missing.txt", t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := missing.Refresh(context.Background()); err == nil {
+		t.Fatalf("404 list should fail")
+	}
+	if _, err := Open("file:///etc/passwd", t.TempDir(), Options{}); err == nil {
+		t.Fatalf("non-http list url should be rejected")
+	}
+}
+
+func TestNext_PrefersNewImages(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	for _, name := range []string{"a", "b", "c"} {
+		srv.setImage("/"+name+".png", "image-"+name)
+	}
+	srv.setList("/a.png", "/b.png")
+	dir := t.TempDir()
+	src, err := Open(srv.URL+"/list.txt", dir, Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := src.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	first := func(n int) int { return 0 }
+	ctx := context.Background()
+	p1, _ := src.Next(ctx, "", first)
+	p2, _ := src.Next(ctx, p1, first)
+	if p1 == p2 || p1 == "" || p2 == "" {
+		t.Fatalf("both new images should be shown once: %q %q", p1, p2)
+	}
+
+	srv.setList("/a.png", "/b.png", "/c.png")
+	if added, err := src.Refresh(ctx); err != nil || added != 1 {
+		t.Fatalf("Refresh = %d, %v", added, err)
+	}
+	p3, err := src.Next(ctx, p2, first)
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if data, _ := os.ReadFile(p3); string(data) != "image-c" {
+		t.Fatalf("the new image should be shown first, got %q", data)
+	}
+
+	// 都显示过后从全部图片里选，避开上一张
+	p4, _ := src.Next(ctx, p1, first)
+	if p4 == p1 {
+		t.Fatalf("should not repeat the last image")
+	}
+
+	// 进度保存在缓存目录里，重新打开后不会把旧图当成新图
+	reopened, err := Open(srv.URL+"/list.txt", dir, Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, e := range reopened.Entries() {
+		if !e.Shown {
+			t.Fatalf("%s should stay shown after reopening", e.URL)
+		}
+	}
+	other, err := Open(srv.URL+"/other.txt", dir, Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(other.Entries()) != 0 {
+		t.Fatalf("a different list url should start fresh")
+	}
+}
+
+func TestNext_SkipsBrokenImages(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.setImage("/good.png", "image-good")
+	srv.setImage("/big.png", strings.Repeat("x", 64))
+	srv.setImage("/bad.png", "not an image")
+	srv.setList("/missing.png", "/big.png", "/bad.png", "/good.png")
+	src, err := Open(srv.URL+"/list.txt", t.TempDir(), Options{
+		MaxImageBytes: 32,
+		Validate: func(path string) bool {
+			data, err := os.ReadFile(path)
+			return err == nil && strings.HasPrefix(string(data), "image-")
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	// 只更新列表不下载，让 Next 现场下载
+	srv.setImage("/good.png", "image-good")
+	p, err := src.Next(context.Background(), "", func(int) int { return 0 })
+	if err == nil {
+		t.Fatalf("Next before Refresh should have nothing to pick, got %q", p)
+	}
+	if _, err := src.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	p, err = src.Next(context.Background(), "", func(int) int { return 0 })
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if data, _ := os.ReadFile(p); string(data) != "image-good" {
+		t.Fatalf("Next = %q, want the only valid image", data)
+	}
+}
+
+func TestEvict_KeepsCacheUnderLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	for _, name := range []string{"a", "b", "c"} {
+		srv.setImage("/"+name+".png", "image-"+name+strings.Repeat("0", 10))
+	}
+	srv.setList("/a.png", "/b.png", "/c.png")
+
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	src, err := Open(srv.URL+"/list.txt", dir, Options{
+		MaxCacheBytes: 40,
+		Now: func() time.Time {
+			now = now.Add(time.Minute)
+			return now
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := src.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	ctx := context.Background()
+	first := func(int) int { return 0 }
+	var shown []string
+	for i := 0; i < 3; i++ {
+		p, err := src.Next(ctx, "", first)
+		if err != nil {
+			t.Fatalf("Next %d: %v", i, err)
+		}
+		shown = append(shown, p)
+	}
+
+	var total int64
+	cached := 0
+	for _, e := range src.Entries() {
+		if e.File != "" {
+			total += e.Size
+			cached++
+		}
+	}
+	if total > 40 || cached != 2 {
+		t.Fatalf("cache holds %d images, %d bytes, want 2 images under 40 bytes", cached, total)
+	}
+	if _, err := os.Stat(shown[0]); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("least recently used image should be evicted")
+	}
+	if _, err := os.Stat(shown[2]); err != nil {
+		t.Fatalf("the image just picked must stay cached: %v", err)
+	}
+	// 被清理的图再次选中时重新下载
+	p, err := src.Next(ctx, shown[2], func(n int) int { return n - 1 })
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if _, err := os.Stat(p); err != nil {
+		t.Fatalf("re-picked image should be downloaded again: %v", err)
+	}
+}
+
+func TestRefresh_PrefetchStaysWithinCacheLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	var lines []string
+	for i := 0; i < 6; i++ {
+		p := fmt.Sprintf("/%d.png", i)
+		srv.setImage(p, "image-"+strings.Repeat("0", 10))
+		lines = append(lines, p)
+	}
+	srv.setList(lines...)
+	src, err := Open(srv.URL+"/list.txt", t.TempDir(), Options{MaxCacheBytes: 40})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := src.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	var total int64
+	for _, e := range src.Entries() {
+		total += e.Size
+	}
+	if total > 40 || total == 0 {
+		t.Fatalf("prefetched %d bytes, want some but at most 40", total)
+	}
+	// 到了上限就不再下载后面的图
+	if reqs, _ := srv.counts("/5.png"); reqs != 0 {
+		t.Fatalf("images past the cache limit should not be prefetched, got %d requests", reqs)
+	}
+}
+
+func TestRefresh_ConcurrentCallsLeaveNoUntrackedFiles(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		srv.setImage("/"+name+".png", "image-"+name)
+	}
+	srv.setList("/a.png", "/b.png", "/c.png")
+	dir := t.TempDir()
+	src, err := Open(srv.URL+"/list.txt", dir, Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 2 {
+				srv.setList("/c.png", "/d.png")
+			}
+			if _, err := src.Refresh(context.Background()); err != nil {
+				t.Errorf("Refresh: %v", err)
+			}
+			if _, err := src.Next(context.Background(), "", func(int) int { return 0 }); err != nil {
+				t.Errorf("Next: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	tracked := map[string]bool{manifestName: true}
+	for _, e := range src.Entries() {
+		if e.File != "" {
+			tracked[e.File] = true
+		}
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if !tracked[f.Name()] {
+			t.Fatalf("cache file %s is not tracked by the manifest", f.Name())
+		}
+	}
+}
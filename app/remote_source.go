@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haua/futu/app/remote"
+)
+
+const (
+	imageSourceModeRemote = "remote"
+	remoteListURLPrefKey  = "image.remote_url"
+
+	// 一次更新列表加下载的最长时间
+	remoteRefreshTimeout = 10 * time.Minute
+)
+
+func defaultRemoteCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, startupValueName, "remote")
+}
+
+func (f *FloatingWindow) RemoteListURL() string {
+	if f == nil {
+		return ""
+	}
+	f.imageSourceMu.Lock()
+	defer f.imageSourceMu.Unlock()
+	return strings.TrimSpace(f.remoteListURL)
+}
+
+// remoteSource 返回 listURL 对应的缓存，地址变了时重新打开。
+func (f *FloatingWindow) remoteSource(listURL string) (*remote.Source, error) {
+	f.remoteMu.Lock()
+	defer f.remoteMu.Unlock()
+	if f.remote != nil && f.remote.ListURL() == listURL {
+		return f.remote, nil
+	}
+	dir := f.remoteCacheDir
+	if dir == "" {
+		dir = defaultRemoteCacheDir()
+	}
+	src, err := remote.Open(listURL, dir, remote.Options{Validate: isSupportedImagePath})
+	if err != nil {
+		return nil, err
+	}
+	f.remote = src
+	return src, nil
+}
+
+// SetRemoteList 切换到网络图片列表：先更新一次列表，能拿到图片才保存并开始换图。
+// 会访问网络，不要在界面线程调用。
+func (f *FloatingWindow) SetRemoteList(listURL string) (int, error) {
+	if f == nil {
+		return 0, nil
+	}
+	listURL = strings.TrimSpace(listURL)
+	src, err := f.remoteSource(listURL)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remoteRefreshTimeout)
+	defer cancel()
+	added, err := src.Refresh(ctx)
+	if err != nil && len(src.Entries()) == 0 {
+		return 0, err
+	}
+	if err != nil {
+		// 网络不通但有缓存时照常使用
+		log.Printf("refresh remote list failed, using cache: %v", err)
+	}
+
+	f.imageSourceMu.Lock()
+	f.remoteListURL = listURL
+	f.imageSourceMode = imageSourceModeRemote
+	f.lastRemoteImagePath = ""
+	f.saveImageSourceLocked()
+	f.imageSourceMu.Unlock()
+
+	f.startImageTicker()
+	if !f.playFromRemote() {
+		return added, remote.ErrNoImage
+	}
+	return added, nil
+}
+
+// RefreshRemoteNow 立即更新列表，有新图片时马上显示其中一张。会访问网络，不要在界面线程调用。
+func (f *FloatingWindow) RefreshRemoteNow() (int, error) {
+	listURL := f.RemoteListURL()
+	if listURL == "" {
+		return 0, errors.New("还没有设置图片列表地址")
+	}
+	src, err := f.remoteSource(listURL)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remoteRefreshTimeout)
+	defer cancel()
+	added, err := src.Refresh(ctx)
+	if err != nil {
+		return added, err
+	}
+	if added > 0 && f.ImageSourceMode() == imageSourceModeRemote {
+		f.playFromRemote()
+	}
+	return added, nil
+}
+
+// playFromRemote 按需更新列表后显示下一张，先显示没显示过的新图。
+func (f *FloatingWindow) playFromRemote() bool {
+	listURL := f.RemoteListURL()
+	if listURL == "" {
+		return false
+	}
+	src, err := f.remoteSource(listURL)
+	if err != nil {
+		log.Printf("open remote source failed: %v", err)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remoteRefreshTimeout)
+	defer cancel()
+	if src.NeedsRefresh() {
+		if _, err := src.Refresh(ctx); err != nil {
+			log.Printf("refresh remote list failed: %v", err)
+		}
+	}
+
+	f.imageSourceMu.Lock()
+	last := f.lastRemoteImagePath
+	randIntn := f.randomIntn
+	f.imageSourceMu.Unlock()
+
+	path, err := src.Next(ctx, last, randIntn)
+	if err != nil {
+		log.Printf("pick remote image failed: %v", err)
+		return false
+	}
+	f.imageSourceMu.Lock()
+	f.lastRemoteImagePath = path
+	f.imageSourceMu.Unlock()
+	f.playImagePath(path)
+	return true
+}
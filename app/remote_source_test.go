@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestSetRemoteList_PlaysAndPersists(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list.txt":
+			fmt.Fprintln(w, "# images")
+			fmt.Fprintln(w, "/a.png")
+		case "/a.png":
+			w.Write(img.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	p := player.NewPlayer(a, w)
+	t.Cleanup(p.Close)
+	fw := &FloatingWindow{App: a, Player: p, imageTickerInterval: 24 * time.Hour, remoteCacheDir: t.TempDir()}
+	t.Cleanup(fw.stopImageTicker)
+
+	if _, err := fw.SetRemoteList(srv.URL + "/missing.txt"); err == nil {
+		t.Fatalf("a list that cannot be fetched should be rejected")
+	}
+	if fw.ImageSourceMode() == imageSourceModeRemote {
+		t.Fatalf("mode should not change when the list fails")
+	}
+
+	added, err := fw.SetRemoteList(" " + srv.URL + "/list.txt ")
+	if err != nil || added != 1 {
+		t.Fatalf("SetRemoteList = %d, %v", added, err)
+	}
+	if fw.ImageSourceMode() != imageSourceModeRemote || fw.RemoteListURL() != srv.URL+"/list.txt" {
+		t.Fatalf("mode = %q, url = %q", fw.ImageSourceMode(), fw.RemoteListURL())
+	}
+	if got := a.Preferences().String(remoteListURLPrefKey); got != srv.URL+"/list.txt" {
+		t.Fatalf("saved url = %q", got)
+	}
+	if !strings.HasPrefix(p.CurrentPath(), fw.remoteCacheDir) {
+		t.Fatalf("current = %q, want a cached image", p.CurrentPath())
+	}
+
+	if added, err := fw.RefreshRemoteNow(); err != nil || added != 0 {
+		t.Fatalf("RefreshRemoteNow = %d, %v", added, err)
+	}
+}
+
+func TestPlayRandomImageNow_RemoteDoesNotBlock(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.URL.Path == "/list.txt" {
+			fmt.Fprintln(w, "/a.png")
+			return
+		}
+		w.Write(img.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	p := player.NewPlayer(a, w)
+	t.Cleanup(p.Close)
+	fw := &FloatingWindow{
+		App:             a,
+		Player:          p,
+		imageSourceMode: imageSourceModeRemote,
+		remoteListURL:   srv.URL + "/list.txt",
+		remoteCacheDir:  t.TempDir(),
+	}
+
+	// 服务器还没响应，按钮回调也要立即返回
+	done := make(chan bool)
+	go func() { done <- fw.PlayRandomImageNow() }()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatalf("PlayRandomImageNow should start loading the remote image")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("PlayRandomImageNow blocked on the network")
+	}
+
+	close(release)
+	waitFor(t, "remote image shown", func() bool {
+		return strings.HasPrefix(p.CurrentPath(), fw.remoteCacheDir)
+	})
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	rotationOrderPrefKey = "image.rotate_order"
+	shuffleBagPrefKey    = "image.shuffle_bag"
+	rotationLastPrefKey  = "image.rotate_last"
+
+	// 每次随机选一张，只避免和上一张重复
+	rotationOrderRandom = "random"
+	// 洗牌：全部显示一遍后再重新打乱
+	rotationOrderShuffle = "shuffle"
+	rotationOrderName    = "name"
+	rotationOrderMTime   = "mtime"
+)
+
+var rotationOrders = []struct {
+	name  string
+	label string
+}{
+	{rotationOrderRandom, "随机"},
+	{rotationOrderShuffle, "洗牌（每张都显示过再重复）"},
+	{rotationOrderName, "按文件名顺序"},
+	{rotationOrderMTime, "按修改时间顺序"},
+}
+
+func normalizeRotationOrder(order string) string {
+	order = strings.ToLower(strings.TrimSpace(order))
+	for _, item := range rotationOrders {
+		if item.name == order {
+			return order
+		}
+	}
+	return rotationOrderRandom
+}
+
+func rotationOrderLabel(order string) string {
+	order = normalizeRotationOrder(order)
+	for _, item := range rotationOrders {
+		if item.name == order {
+			return item.label
+		}
+	}
+	return rotationOrders[0].label
+}
+
+func rotationOrderFromLabel(label string) string {
+	for _, item := range rotationOrders {
+		if item.label == label {
+			return item.name
+		}
+	}
+	return rotationOrderRandom
+}
+
+// shuffleBag 记录本轮洗牌的进度：Remaining 是还没显示的图，按顺序取；Shown 是本轮已显示的图。
+type shuffleBag struct {
+	Remaining []string `json:"remaining"`
+	Shown     []string `json:"shown"`
+}
+
+func shuffleStrings(items []string, randIntn func(int) int) {
+	if randIntn == nil {
+		return
+	}
+	for i := len(items) - 1; i > 0; i-- {
+		j := randIntn(i + 1)
+		if j < 0 || j > i {
+			continue
+		}
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+// pickFromShuffleBag 从洗牌袋里取下一张。已删除的图从袋里去掉，新加的图随机插进本轮还没显示的部分，
+// 袋空了就重新洗牌，并避免新一轮的第一张和上一张相同。
+func pickFromShuffleBag(bag shuffleBag, candidates []string, last string, randIntn func(int) int) (string, shuffleBag) {
+	if len(candidates) == 0 {
+		return "", shuffleBag{}
+	}
+	exists := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		exists[c] = true
+	}
+	known := make(map[string]bool, len(candidates))
+	keep := func(paths []string) []string {
+		out := make([]string, 0, len(paths))
+		for _, p := range paths {
+			if exists[p] && !known[p] {
+				known[p] = true
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+	shown := keep(bag.Shown)
+	remaining := keep(bag.Remaining)
+	for _, c := range candidates {
+		if known[c] {
+			continue
+		}
+		i := len(remaining)
+		if randIntn != nil {
+			if n := randIntn(len(remaining) + 1); n >= 0 && n <= len(remaining) {
+				i = n
+			}
+		}
+		remaining = append(remaining, "")
+		copy(remaining[i+1:], remaining[i:])
+		remaining[i] = c
+	}
+
+	if len(remaining) == 0 {
+		remaining = append([]string(nil), candidates...)
+		shuffleStrings(remaining, randIntn)
+		shown = nil
+		if len(remaining) > 1 && remaining[0] == last {
+			remaining[0], remaining[len(remaining)-1] = remaining[len(remaining)-1], remaining[0]
+		}
+	}
+	picked := remaining[0]
+	return picked, shuffleBag{Remaining: remaining[1:], Shown: append(shown, picked)}
+}
+
+// pickNextInOrder 按顺序取 last 的下一张，到末尾后回到第一张。
+// last 已被删除时，按文件名顺序从它原来的位置继续，按修改时间顺序从头开始。
+func pickNextInOrder(ordered []string, last string, byName bool) string {
+	if len(ordered) == 0 {
+		return ""
+	}
+	for i, p := range ordered {
+		if p == last {
+			return ordered[(i+1)%len(ordered)]
+		}
+	}
+	if byName && last != "" {
+		if i := sort.SearchStrings(ordered, last); i < len(ordered) {
+			return ordered[i]
+		}
+	}
+	return ordered[0]
+}
+
+// sortByModTime 按修改时间从旧到新排序，时间相同按路径。
+func sortByModTime(paths []string) []string {
+	type entry struct {
+		path string
+		mod  int64
+	}
+	entries := make([]entry, 0, len(paths))
+	for _, p := range paths {
+		var mod int64
+		if info, err := os.Stat(p); err == nil {
+			mod = info.ModTime().UnixNano()
+		}
+		entries = append(entries, entry{p, mod})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].mod != entries[j].mod {
+			return entries[i].mod < entries[j].mod
+		}
+		return entries[i].path < entries[j].path
+	})
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.path
+	}
+	return out
+}
+
+// restoreRotationOrder 读取换图顺序和上次的进度。
+func (f *FloatingWindow) restoreRotationOrder() {
+	if f == nil || f.App == nil {
+		return
+	}
+	prefs := f.App.Preferences()
+	order := normalizeRotationOrder(prefs.String(rotationOrderPrefKey))
+	var bag shuffleBag
+	if raw := strings.TrimSpace(prefs.String(shuffleBagPrefKey)); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &bag); err != nil {
+			log.Printf("load shuffle bag failed: %v", err)
+			bag = shuffleBag{}
+		}
+	}
+
+	f.imageSourceMu.Lock()
+	f.rotationOrder = order
+	f.shuffleBag = bag
+	f.lastRandomImagePath = strings.TrimSpace(prefs.String(rotationLastPrefKey))
+	f.imageSourceMu.Unlock()
+}
+
+func (f *FloatingWindow) RotationOrder() string {
+	if f == nil {
+		return rotationOrderRandom
+	}
+	f.imageSourceMu.Lock()
+	defer f.imageSourceMu.Unlock()
+	return normalizeRotationOrder(f.rotationOrder)
+}
+
+// SetRotationOrder 修改换图顺序，洗牌进度从头开始。
+func (f *FloatingWindow) SetRotationOrder(order string) {
+	if f == nil {
+		return
+	}
+	order = normalizeRotationOrder(order)
+	f.imageSourceMu.Lock()
+	f.rotationOrder = order
+	f.shuffleBag = shuffleBag{}
+	f.saveRotationLocked()
+	f.imageSourceMu.Unlock()
+}
+
+func (f *FloatingWindow) saveRotationLocked() {
+	if f.App == nil {
+		return
+	}
+	prefs := f.App.Preferences()
+	prefs.SetString(rotationOrderPrefKey, normalizeRotationOrder(f.rotationOrder))
+	prefs.SetString(rotationLastPrefKey, f.lastRandomImagePath)
+	data, err := json.Marshal(f.shuffleBag)
+	if err != nil {
+		log.Printf("save shuffle bag failed: %v", err)
+		return
+	}
+	prefs.SetString(shuffleBagPrefKey, string(data))
+}
+
+// pickFolderImage 按当前换图顺序选出下一张并记下进度。
+func (f *FloatingWindow) pickFolderImage(candidates []string) string {
+	f.imageSourceMu.Lock()
+	defer f.imageSourceMu.Unlock()
+
+	last := f.lastRandomImagePath
+	var picked string
+	switch normalizeRotationOrder(f.rotationOrder) {
+	case rotationOrderShuffle:
+		picked, f.shuffleBag = pickFromShuffleBag(f.shuffleBag, candidates, last, f.randomIntn)
+	case rotationOrderName:
+		picked = pickNextInOrder(candidates, last, true)
+	case rotationOrderMTime:
+		picked = pickNextInOrder(sortByModTime(candidates), last, false)
+	default:
+		picked = pickRandomImagePath(candidates, last, f.randomIntn)
+	}
+	if picked != "" {
+		f.lastRandomImagePath = picked
+		f.saveRotationLocked()
+	}
+	return picked
+}
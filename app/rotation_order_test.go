@@ -0,0 +1,135 @@
+package app
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestPickFromShuffleBag_ShowsEveryImageOncePerCycle(t *testing.T) {
+	t.Parallel()
+
+	candidates := []string{"a", "b", "c", "d", "e"}
+	rnd := rand.New(rand.NewSource(1)).Intn
+	var bag shuffleBag
+	last := ""
+	for cycle := 0; cycle < 3; cycle++ {
+		seen := make(map[string]bool)
+		for i := range candidates {
+			var picked string
+			picked, bag = pickFromShuffleBag(bag, candidates, last, rnd)
+			if seen[picked] {
+				t.Fatalf("cycle %d pick %d repeated %q", cycle, i, picked)
+			}
+			if picked == last {
+				t.Fatalf("cycle %d started with the previous image %q", cycle, picked)
+			}
+			seen[picked] = true
+			last = picked
+		}
+		if len(bag.Remaining) != 0 {
+			t.Fatalf("bag should be empty after a full cycle, got %q", bag.Remaining)
+		}
+	}
+}
+
+func TestPickFromShuffleBag_AddedAndRemovedImages(t *testing.T) {
+	t.Parallel()
+
+	bag := shuffleBag{Remaining: []string{"c", "gone", "d"}, Shown: []string{"a", "b"}}
+	// 新图 e 插在末尾
+	picked, bag := pickFromShuffleBag(bag, []string{"a", "b", "c", "d", "e"}, "b", nil)
+	if picked != "c" {
+		t.Fatalf("picked = %q, want c", picked)
+	}
+	if got := bag.Remaining; len(got) != 2 || got[0] != "d" || got[1] != "e" {
+		t.Fatalf("remaining = %q, want [d e]", got)
+	}
+	if got := bag.Shown; len(got) != 3 || got[2] != "c" {
+		t.Fatalf("shown = %q", got)
+	}
+}
+
+func TestPickNextInOrder(t *testing.T) {
+	t.Parallel()
+
+	ordered := []string{"a", "c", "e"}
+	tests := []struct {
+		last   string
+		byName bool
+		want   string
+	}{
+		{"", true, "a"},
+		{"a", true, "c"},
+		{"e", true, "a"},
+		{"b", true, "c"},
+		{"z", true, "a"},
+		{"b", false, "a"},
+	}
+	for _, tt := range tests {
+		if got := pickNextInOrder(ordered, tt.last, tt.byName); got != tt.want {
+			t.Fatalf("pickNextInOrder(%q, %v) = %q, want %q", tt.last, tt.byName, got, tt.want)
+		}
+	}
+}
+
+func TestSortByModTime(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var paths []string
+	for i, name := range []string{"a.png", "b.png", "c.png"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, fakeImageData(path), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		mod := base.Add(time.Duration(3-i) * time.Hour)
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	got := sortByModTime(paths)
+	if got[0] != paths[2] || got[2] != paths[0] {
+		t.Fatalf("sortByModTime = %q", got)
+	}
+}
+
+func TestRotationOrder_ShuffleProgressSurvivesRestart(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+
+	candidates := []string{"a", "b", "c", "d"}
+	fw := &FloatingWindow{App: a, randomIntn: rand.New(rand.NewSource(2)).Intn}
+	fw.restoreRotationOrder()
+	if got := fw.RotationOrder(); got != rotationOrderRandom {
+		t.Fatalf("default order = %q, want random", got)
+	}
+	fw.SetRotationOrder(rotationOrderShuffle)
+	first := fw.pickFolderImage(candidates)
+	second := fw.pickFolderImage(candidates)
+
+	restored := &FloatingWindow{App: a, randomIntn: rand.New(rand.NewSource(3)).Intn}
+	restored.restoreRotationOrder()
+	if got := restored.RotationOrder(); got != rotationOrderShuffle {
+		t.Fatalf("restored order = %q", got)
+	}
+	seen := map[string]bool{first: true, second: true}
+	for i := 0; i < 2; i++ {
+		picked := restored.pickFolderImage(candidates)
+		if seen[picked] {
+			t.Fatalf("restored bag repeated %q before the cycle ended", picked)
+		}
+		seen[picked] = true
+	}
+
+	restored.SetRotationOrder(rotationOrderName)
+	if got := restored.pickFolderImage(candidates); got == "" {
+		t.Fatalf("name order should pick an image")
+	}
+}
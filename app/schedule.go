@@ -0,0 +1,276 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	imageIntervalPrefKey = "image.rotate_interval_s"
+	imageSchedulePrefKey = "image.rotate_schedule"
+
+	minImageTickInterval = 5 * time.Second
+	maxImageTickInterval = 30 * 24 * time.Hour
+	// 换图计划往后最多找这么久，找不到就当作不会触发
+	imageScheduleHorizon = 5 * 366 * 24 * time.Hour
+)
+
+// imageSchedule 是 cron 风格的换图计划，五段依次是 分 时 日 月 周，
+// 比如工作日 9 点到 18 点每 15 分钟换一张写成 "*/15 9-17 * * 1-5"。
+type imageSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和周都有限制时，满足任意一个即可，和 cron 一致
+	domAny, dowAny bool
+}
+
+var scheduleAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+var scheduleMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var scheduleDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parseImageSchedule 解析换图计划，空字符串返回 nil 表示按固定间隔换图。
+func parseImageSchedule(text string) (*imageSchedule, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return nil, nil
+	}
+	if alias, ok := scheduleAliases[text]; ok {
+		text = alias
+	}
+	fields := strings.Fields(text)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("换图计划需要 5 段（分 时 日 月 周），实际 %d 段", len(fields))
+	}
+
+	s := &imageSchedule{}
+	var err error
+	if s.minute, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseScheduleField(fields[3], 1, 12, scheduleMonthNames); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseScheduleField(fields[4], 0, 7, scheduleDayNames); err != nil {
+		return nil, err
+	}
+	// 周日可以写成 0 或 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+// parseScheduleField 解析一段，支持 *、数字、a-b、逗号列表和 /步长。
+func parseScheduleField(field string, lo, hi int, names map[string]int) (uint64, error) {
+	value := func(text string) (int, error) {
+		if n, ok := names[text]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil || n < lo || n > hi {
+			return 0, fmt.Errorf("换图计划中的 %q 超出范围 %d-%d", text, lo, hi)
+		}
+		return n, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("换图计划中的步长 %q 无效", stepText)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rangeText == "*":
+		case strings.Contains(rangeText, "-"):
+			a, b, _ := strings.Cut(rangeText, "-")
+			var err error
+			if start, err = value(a); err != nil {
+				return 0, err
+			}
+			if end, err = value(b); err != nil {
+				return 0, err
+			}
+			// 星期里 0 和 7 都是周日，写在范围末尾时按 7 算，mon-sun、fri-sun 才是一整段
+			if hi == 7 && end == 0 && start > 0 {
+				end = 7
+			}
+			if start > end {
+				return 0, fmt.Errorf("换图计划中的范围 %q 无效", rangeText)
+			}
+		default:
+			var err error
+			if start, err = value(rangeText); err != nil {
+				return 0, err
+			}
+			end = start
+			// 5/10 表示从 5 开始每 10 个
+			if hasStep {
+				end = hi
+			}
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << i
+		}
+	}
+	return bits, nil
+}
+
+func (s *imageSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// next 返回 after 之后第一个满足计划的整分钟。
+func (s *imageSchedule) next(after time.Time) (time.Time, bool) {
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute()+1, 0, 0, loc)
+	limit := after.Add(imageScheduleHorizon)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case s.month&(1<<int(m)) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = time.Date(y, m, d, t.Hour(), t.Minute()+1, 0, 0, loc)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func clampImageTickInterval(d time.Duration) time.Duration {
+	if d <= 0 {
+		return imageTickInterval
+	}
+	return min(max(d, minImageTickInterval), maxImageTickInterval)
+}
+
+// restoreImageRotation 读取换图间隔和换图计划，计划写错时按固定间隔换图。
+func (f *FloatingWindow) restoreImageRotation() {
+	if f == nil || f.App == nil {
+		return
+	}
+	prefs := f.App.Preferences()
+	interval := imageTickInterval
+	if secs := prefs.Int(imageIntervalPrefKey); secs > 0 {
+		interval = time.Duration(secs) * time.Second
+	}
+	text := strings.TrimSpace(prefs.String(imageSchedulePrefKey))
+	sched, err := parseImageSchedule(text)
+	if err != nil {
+		text, sched = "", nil
+	}
+
+	f.imageSourceMu.Lock()
+	f.imageTickerInterval = clampImageTickInterval(interval)
+	f.imageScheduleText = text
+	f.imageSchedule = sched
+	f.imageSourceMu.Unlock()
+}
+
+func (f *FloatingWindow) ImageRotation() (time.Duration, string) {
+	if f == nil {
+		return imageTickInterval, ""
+	}
+	f.imageSourceMu.Lock()
+	defer f.imageSourceMu.Unlock()
+	return clampImageTickInterval(f.imageTickerInterval), f.imageScheduleText
+}
+
+// SetImageRotation 修改换图间隔和换图计划。计划不为空时按计划换图，忽略间隔。
+// 正在按文件夹换图时立即按新设置重新计时。
+func (f *FloatingWindow) SetImageRotation(interval time.Duration, schedule string) error {
+	if f == nil {
+		return nil
+	}
+	schedule = strings.Join(strings.Fields(schedule), " ")
+	sched, err := parseImageSchedule(schedule)
+	if err != nil {
+		return err
+	}
+	if sched != nil {
+		if _, ok := sched.next(time.Now()); !ok {
+			return fmt.Errorf("换图计划 %q 不会触发", schedule)
+		}
+	}
+	interval = clampImageTickInterval(interval)
+
+	f.imageSourceMu.Lock()
+	f.imageTickerInterval = interval
+	f.imageScheduleText = schedule
+	f.imageSchedule = sched
+	running := f.imageTickerStop != nil
+	f.imageSourceMu.Unlock()
+
+	if f.App != nil {
+		prefs := f.App.Preferences()
+		prefs.SetInt(imageIntervalPrefKey, int(interval/time.Second))
+		prefs.SetString(imageSchedulePrefKey, schedule)
+	}
+	if running {
+		f.stopImageTicker()
+		f.startImageTicker()
+	}
+	return nil
+}
+
+// nextImageTickDelay 返回距离下次换图的时间。
+func (f *FloatingWindow) nextImageTickDelay(now time.Time) time.Duration {
+	f.imageSourceMu.Lock()
+	interval := f.imageTickerInterval
+	sched := f.imageSchedule
+	f.imageSourceMu.Unlock()
+
+	if sched == nil {
+		if interval <= 0 {
+			return imageTickInterval
+		}
+		return interval
+	}
+	next, ok := sched.next(now)
+	if !ok {
+		return maxImageTickInterval
+	}
+	return next.Sub(now)
+}
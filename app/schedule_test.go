@@ -0,0 +1,150 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+func TestParseImageSchedule_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "* * * * tue-mon"} {
+		if _, err := parseImageSchedule(text); err == nil {
+			t.Fatalf("parseImageSchedule(%q) should fail", text)
+		}
+	}
+	if s, err := parseImageSchedule("  "); s != nil || err != nil {
+		t.Fatalf("empty schedule = %v, %v, want nil", s, err)
+	}
+}
+
+func TestParseImageSchedule_DayRanges(t *testing.T) {
+	t.Parallel()
+
+	days := func(ds ...time.Weekday) uint64 {
+		var bits uint64
+		for _, d := range ds {
+			bits |= 1 << d
+		}
+		return bits
+	}
+	all := days(time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday)
+	weekend := days(time.Friday, time.Saturday, time.Sunday)
+	tests := []struct {
+		field string
+		want  uint64
+	}{
+		{"mon-sun", all},
+		{"sun-sat", all},
+		{"fri-sun", weekend},
+		{"5-7", weekend},
+		{"5-0", weekend},
+		{"sat-sun", days(time.Saturday, time.Sunday)},
+		{"mon-fri", days(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)},
+		{"sun", days(time.Sunday)},
+		{"sun-sun", days(time.Sunday)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.field, func(t *testing.T) {
+			t.Parallel()
+			s, err := parseImageSchedule("0 8 * * " + tt.field)
+			if err != nil {
+				t.Fatalf("parseImageSchedule: %v", err)
+			}
+			// 第 7 位是周日的别名，只看 0~6
+			if got := s.dow & all; got != tt.want {
+				t.Fatalf("days of %q = %07b, want %07b", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	// 2026-03-13 是周五
+	friday := func(h, m int) time.Time { return time.Date(2026, 3, 13, h, m, 30, 0, time.UTC) }
+	tests := []struct {
+		name  string
+		sched string
+		after time.Time
+		want  time.Time
+	}{
+		{"next quarter", "*/15 9-17 * * 1-5", friday(10, 7), friday(10, 15).Add(-30 * time.Second)},
+		{"before window", "*/15 9-17 * * 1-5", friday(6, 0), friday(9, 0).Add(-30 * time.Second)},
+		{"skip weekend", "*/15 9-17 * * mon-fri", friday(17, 50), time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"hourly alias", "@hourly", friday(10, 0), friday(11, 0).Add(-30 * time.Second)},
+		{"sunday as seven", "0 8 * * 7", friday(10, 0), time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)},
+		{"range ending on sunday", "0 8 * * sat-sun", friday(10, 0), time.Date(2026, 3, 14, 8, 0, 0, 0, time.UTC)},
+		{"day of month or weekday", "0 0 20 * 6", friday(10, 0), time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"month and offset step", "5/20 0 1 apr *", friday(10, 0), time.Date(2026, 4, 1, 0, 5, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := parseImageSchedule(tt.sched)
+			if err != nil {
+				t.Fatalf("parseImageSchedule: %v", err)
+			}
+			got, ok := s.next(tt.after)
+			if !ok || !got.Equal(tt.want) {
+				t.Fatalf("next(%v) = %v, %v, want %v", tt.after, got, ok, tt.want)
+			}
+		})
+	}
+
+	s, _ := parseImageSchedule("0 0 30 2 *")
+	if _, ok := s.next(friday(0, 0)); ok {
+		t.Fatalf("February 30th should never fire")
+	}
+}
+
+func TestSetImageRotation_PersistAndReschedule(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+
+	fw := &FloatingWindow{App: a}
+	fw.restoreImageRotation()
+	if interval, sched := fw.ImageRotation(); interval != imageTickInterval || sched != "" {
+		t.Fatalf("default rotation = %v %q", interval, sched)
+	}
+
+	if err := fw.SetImageRotation(time.Minute, "0 0 30 2 *"); err == nil {
+		t.Fatalf("a schedule that never fires should be rejected")
+	}
+	if err := fw.SetImageRotation(time.Second, ""); err != nil {
+		t.Fatalf("SetImageRotation: %v", err)
+	}
+	if interval, _ := fw.ImageRotation(); interval != minImageTickInterval {
+		t.Fatalf("interval should be clamped to %v, got %v", minImageTickInterval, interval)
+	}
+	if got := fw.nextImageTickDelay(time.Now()); got != minImageTickInterval {
+		t.Fatalf("next delay = %v", got)
+	}
+
+	fw.startImageTicker()
+	t.Cleanup(fw.stopImageTicker)
+	if err := fw.SetImageRotation(90*time.Minute, "  */15   9-17 * * 1-5 "); err != nil {
+		t.Fatalf("SetImageRotation: %v", err)
+	}
+	fw.imageSourceMu.Lock()
+	running := fw.imageTickerStop != nil
+	fw.imageSourceMu.Unlock()
+	if !running {
+		t.Fatalf("ticker should keep running after rescheduling")
+	}
+	now := time.Date(2026, 3, 13, 10, 7, 0, 0, time.Local)
+	if got := fw.nextImageTickDelay(now); got != 8*time.Minute {
+		t.Fatalf("scheduled delay = %v, want 8m", got)
+	}
+
+	restored := &FloatingWindow{App: a}
+	restored.restoreImageRotation()
+	if interval, sched := restored.ImageRotation(); interval != 90*time.Minute || sched != "*/15 9-17 * * 1-5" {
+		t.Fatalf("restored rotation = %v %q", interval, sched)
+	}
+}
@@ -1,14 +1,19 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"image/color"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	desktopdrv "fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
+	"github.com/haua/futu/app/player"
 	"github.com/haua/futu/app/utils"
 	sqweek "github.com/sqweek/dialog"
 )
@@ -43,6 +48,9 @@ func operationGuideText() string {
 		"2. 双击托盘图标可切换编辑模式与常态模式",
 		"3. 编辑模式支持拖拽窗口、滚轮缩放",
 		"4. 常态模式会在鼠标靠近时隐藏窗口，不影响你的操作",
+		"5. 编辑模式下播放动图时：空格暂停/继续，←/→ 单帧步进，↑/↓ 调整速度，0 恢复原速",
+		"6. 编辑模式下按 C 裁剪：拖出要保留的区域后双击或回车确认，右键或 Esc 取消",
+		"7. 编辑模式下按 R/L 顺时针/逆时针旋转 90°，H/V 左右/上下翻转，每张图单独记住",
 	}, "\n")
 }
 
@@ -341,15 +349,21 @@ func newModeToggleHotkeySetting(win *FloatingWindow, settingsWin fyne.Window) fy
 }
 
 func imageSourceModeLabel(mode string) string {
-	if mode == imageSourceModeFolder {
-		return "文件夹中随机（每小时换图）"
+	switch mode {
+	case imageSourceModeFolder:
+		return "文件夹轮换"
+	case imageSourceModeRemote:
+		return "网络图片列表"
 	}
 	return "固定图片"
 }
 
 func imageSourceModeFromLabel(label string) string {
-	if strings.Contains(label, "文件夹") {
+	switch {
+	case strings.Contains(label, "文件夹"):
 		return imageSourceModeFolder
+	case strings.Contains(label, "网络"):
+		return imageSourceModeRemote
 	}
 	return imageSourceModeSingle
 }
@@ -377,21 +391,27 @@ func newImageSourceSetting(win *FloatingWindow) fyne.CanvasObject {
 	folderPathLabel.Wrapping = fyne.TextWrapWord
 	fixedSection := container.NewVBox()
 	folderSection := container.NewVBox()
+	remoteSection := container.NewVBox()
 
 	modeRadio := widget.NewRadioGroup([]string{
 		imageSourceModeLabel(imageSourceModeSingle),
 		imageSourceModeLabel(imageSourceModeFolder),
+		imageSourceModeLabel(imageSourceModeRemote),
 	}, nil)
 	modeRadio.Horizontal = true
 
 	switchModeSection := func(mode string) {
-		if mode == imageSourceModeFolder {
-			fixedSection.Hide()
+		fixedSection.Hide()
+		folderSection.Hide()
+		remoteSection.Hide()
+		switch mode {
+		case imageSourceModeFolder:
 			folderSection.Show()
-			return
+		case imageSourceModeRemote:
+			remoteSection.Show()
+		default:
+			fixedSection.Show()
 		}
-		folderSection.Hide()
-		fixedSection.Show()
 	}
 
 	refreshView := func() {
@@ -421,7 +441,7 @@ func newImageSourceSetting(win *FloatingWindow) fyne.CanvasObject {
 			return
 		}
 		refreshView()
-		showError("切换失败：请先选择有效的图片或文件夹（文件夹需包含支持格式）")
+		showError("切换失败：请先选择有效的图片、文件夹（需包含支持格式）或图片列表地址")
 	}
 
 	selectFixedBtn := widget.NewButton("选择图片", func() {
@@ -451,18 +471,137 @@ func newImageSourceSetting(win *FloatingWindow) fyne.CanvasObject {
 		refreshView()
 	})
 
-	randomNowBtn := widget.NewButton("立即随机一张", func() {
+	randomNowBtn := widget.NewButton("立即换一张", func() {
 		if win.PlayRandomImageNow() {
 			hideError()
 			return
 		}
-		showError("随机失败：请先设置有效的图片文件夹")
+		showError("换图失败：请先设置有效的图片文件夹")
+	})
+
+	scan := win.FolderScanOptions()
+	recursiveCheck := widget.NewCheck("包含子文件夹", nil)
+	recursiveCheck.SetChecked(scan.Recursive)
+	depthEntry := widget.NewEntry()
+	depthEntry.SetText(strconv.Itoa(scan.MaxDepth))
+	depthRow := container.NewBorder(nil, nil, widget.NewLabel("最多层数"), nil, depthEntry)
+	patternsEntry := widget.NewMultiLineEntry()
+	patternsEntry.SetPlaceHolder("每行一条，如 **/calm/**，以 ! 开头表示排除，如 !**/drafts/**")
+	patternsEntry.SetText(strings.Join(scan.Patterns, "\n"))
+	patternsEntry.SetMinRowsVisible(3)
+	updateDepth := func(recursive bool) {
+		if recursive {
+			depthRow.Show()
+		} else {
+			depthRow.Hide()
+		}
+	}
+	updateDepth(scan.Recursive)
+	recursiveCheck.OnChanged = updateDepth
+	applyScanBtn := widget.NewButton("应用筛选", func() {
+		depth, err := strconv.Atoi(strings.TrimSpace(depthEntry.Text))
+		if err != nil || depth <= 0 {
+			showError("设置失败：层数需为正整数")
+			return
+		}
+		err = win.SetFolderScanOptions(folderScanOptions{
+			Recursive: recursiveCheck.Checked,
+			MaxDepth:  depth,
+			Patterns:  parseFolderPatterns(patternsEntry.Text),
+		})
+		if err != nil {
+			showError("设置失败：" + err.Error())
+			return
+		}
+		depthEntry.SetText(strconv.Itoa(win.FolderScanOptions().MaxDepth))
+		hideError()
+	})
+
+	remoteEntry := widget.NewEntry()
+	remoteEntry.SetPlaceHolder("https://example.com/images.txt")
+	remoteEntry.SetText(win.RemoteListURL())
+	remoteHint := widget.NewLabel("列表地址返回纯文本，每行一个图片地址。每天自动更新一次，新加入的图片优先显示。")
+	remoteHint.Wrapping = fyne.TextWrapWord
+	// 网络操作放到后台，完成后回到界面线程更新
+	runRemote := func(buttons []*widget.Button, work func() (string, error)) {
+		for _, b := range buttons {
+			b.Disable()
+		}
+		status.SetText("正在下载…")
+		status.Show()
+		go func() {
+			text, err := work()
+			fyne.Do(func() {
+				for _, b := range buttons {
+					b.Enable()
+				}
+				if err != nil {
+					showError("失败：" + err.Error())
+					return
+				}
+				status.SetText(text)
+				status.Show()
+				refreshView()
+			})
+		}()
+	}
+	var remoteButtons []*widget.Button
+	useRemoteBtn := widget.NewButton("使用此列表", func() {
+		listURL := strings.TrimSpace(remoteEntry.Text)
+		runRemote(remoteButtons, func() (string, error) {
+			added, err := win.SetRemoteList(listURL)
+			return fmt.Sprintf("已使用图片列表，新增 %d 张图片", added), err
+		})
+	})
+	refreshRemoteBtn := widget.NewButton("立即更新", func() {
+		runRemote(remoteButtons, func() (string, error) {
+			added, err := win.RefreshRemoteNow()
+			return fmt.Sprintf("更新完成，新增 %d 张图片", added), err
+		})
+	})
+	nextRemoteBtn := widget.NewButton("立即换一张", func() {
+		runRemote(remoteButtons, func() (string, error) {
+			if !win.playFromRemote() {
+				return "", errors.New("没有可显示的网络图片")
+			}
+			return "已换图", nil
+		})
 	})
+	remoteButtons = []*widget.Button{useRemoteBtn, refreshRemoteBtn, nextRemoteBtn}
 
 	fixedSection.Objects = []fyne.CanvasObject{selectFixedBtn, fixedPathLabel}
 	fixedSection.Refresh()
-	folderSection.Objects = []fyne.CanvasObject{container.NewHBox(selectFolderBtn, randomNowBtn), folderPathLabel}
+	orderLabels := make([]string, 0, len(rotationOrders))
+	for _, item := range rotationOrders {
+		orderLabels = append(orderLabels, item.label)
+	}
+	orderSelect := widget.NewSelect(orderLabels, nil)
+	orderSelect.SetSelected(rotationOrderLabel(win.RotationOrder()))
+	orderSelect.OnChanged = func(label string) {
+		win.SetRotationOrder(rotationOrderFromLabel(label))
+	}
+
+	showNewCheck := widget.NewCheck("文件夹里有新图片时立即显示", win.SetShowNewImages)
+	showNewCheck.SetChecked(win.ShowNewImages())
+
+	folderSection.Objects = []fyne.CanvasObject{
+		container.NewHBox(selectFolderBtn, randomNowBtn),
+		folderPathLabel,
+		container.NewBorder(nil, nil, widget.NewLabel("换图顺序"), nil, orderSelect),
+		showNewCheck,
+		recursiveCheck,
+		depthRow,
+		widget.NewLabel("筛选规则"),
+		patternsEntry,
+		container.NewHBox(applyScanBtn),
+	}
 	folderSection.Refresh()
+	remoteSection.Objects = []fyne.CanvasObject{
+		container.NewBorder(nil, nil, widget.NewLabel("列表地址"), nil, remoteEntry),
+		remoteHint,
+		container.NewHBox(useRemoteBtn, refreshRemoteBtn, nextRemoteBtn),
+	}
+	remoteSection.Refresh()
 	switchModeSection(win.ImageSourceMode())
 
 	return container.NewVBox(
@@ -470,10 +609,595 @@ func newImageSourceSetting(win *FloatingWindow) fyne.CanvasObject {
 		modeRadio,
 		fixedSection,
 		folderSection,
+		remoteSection,
+		status,
+	)
+}
+
+var intervalUnits = []struct {
+	label string
+	unit  time.Duration
+}{
+	{"秒", time.Second},
+	{"分钟", time.Minute},
+	{"小时", time.Hour},
+	{"天", 24 * time.Hour},
+}
+
+// splitInterval 用能整除的最大单位显示间隔。
+func splitInterval(d time.Duration) (int, string) {
+	for i := len(intervalUnits) - 1; i >= 0; i-- {
+		if u := intervalUnits[i]; d%u.unit == 0 {
+			return int(d / u.unit), u.label
+		}
+	}
+	return int(d / time.Second), intervalUnits[0].label
+}
+
+func newImageRotationSetting(win *FloatingWindow) fyne.CanvasObject {
+	if win == nil {
+		return widget.NewLabel("无法加载换图间隔设置")
+	}
+
+	interval, schedule := win.ImageRotation()
+	value, unitLabel := splitInterval(interval)
+	valueEntry := widget.NewEntry()
+	valueEntry.SetText(strconv.Itoa(value))
+	unitLabels := make([]string, 0, len(intervalUnits))
+	for _, item := range intervalUnits {
+		unitLabels = append(unitLabels, item.label)
+	}
+	unitSelect := widget.NewSelect(unitLabels, nil)
+	unitSelect.SetSelected(unitLabel)
+	scheduleEntry := widget.NewEntry()
+	scheduleEntry.SetPlaceHolder("*/15 9-17 * * 1-5")
+	scheduleEntry.SetText(schedule)
+	hint := widget.NewLabel("换图计划按 分 时 日 月 周 填写，例如 */15 9-17 * * 1-5 表示工作日 9 点到 18 点每 15 分钟换一张。填写后忽略换图间隔。")
+	hint.Wrapping = fyne.TextWrapWord
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	status.Hide()
+
+	applyBtn := widget.NewButton("应用", func() {
+		n, err := strconv.Atoi(strings.TrimSpace(valueEntry.Text))
+		if err != nil || n <= 0 {
+			status.SetText("设置失败：间隔需为正整数")
+			status.Show()
+			return
+		}
+		unit := time.Hour
+		for _, item := range intervalUnits {
+			if item.label == unitSelect.Selected {
+				unit = item.unit
+			}
+		}
+		if err := win.SetImageRotation(time.Duration(n)*unit, scheduleEntry.Text); err != nil {
+			status.SetText("设置失败：" + err.Error())
+			status.Show()
+			return
+		}
+		// 超出范围的间隔会被调整，显示实际生效的值
+		interval, schedule := win.ImageRotation()
+		value, unitLabel := splitInterval(interval)
+		valueEntry.SetText(strconv.Itoa(value))
+		unitSelect.SetSelected(unitLabel)
+		scheduleEntry.SetText(schedule)
+		status.Hide()
+	})
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("换图间隔"), unitSelect, valueEntry),
+		container.NewBorder(nil, nil, widget.NewLabel("换图计划"), nil, scheduleEntry),
+		hint,
+		container.NewHBox(applyBtn),
 		status,
 	)
 }
 
+var loopModeLabels = []struct {
+	mode  player.LoopMode
+	label string
+}{
+	{player.LoopAuthored, "按文件设置"},
+	{player.LoopForever, "无限循环"},
+	{player.LoopTimes, "播放指定次数"},
+	{player.LoopPingPong, "往返播放"},
+}
+
+func loopModeLabel(mode player.LoopMode) string {
+	for _, item := range loopModeLabels {
+		if item.mode == mode {
+			return item.label
+		}
+	}
+	return loopModeLabels[0].label
+}
+
+func loopModeFromLabel(label string) player.LoopMode {
+	for _, item := range loopModeLabels {
+		if item.label == label {
+			return item.mode
+		}
+	}
+	return player.LoopAuthored
+}
+
+func loopEndLabel(end player.LoopEnd) string {
+	if end == player.LoopEndFirst {
+		return "播完停在第一帧"
+	}
+	return "播完停在最后一帧"
+}
+
+func newImageLoopSetting(win *FloatingWindow) fyne.CanvasObject {
+	if win == nil || win.Player == nil {
+		return widget.NewLabel("无法加载循环设置")
+	}
+	path := strings.TrimSpace(win.Player.CurrentPath())
+	if path == "" {
+		return widget.NewLabel("动图循环：当前没有播放图片")
+	}
+
+	current := win.ImageLoopSettings(path)
+	labels := make([]string, 0, len(loopModeLabels))
+	for _, item := range loopModeLabels {
+		labels = append(labels, item.label)
+	}
+	modeSelect := widget.NewSelect(labels, nil)
+	countEntry := widget.NewEntry()
+	countEntry.SetPlaceHolder("次数（往返播放填 0 表示无限）")
+	endSelect := widget.NewSelect([]string{
+		loopEndLabel(player.LoopEndLast),
+		loopEndLabel(player.LoopEndFirst),
+	}, nil)
+
+	modeSelect.SetSelected(loopModeLabel(current.Mode))
+	if current.Count > 0 {
+		countEntry.SetText(strconv.Itoa(current.Count))
+	}
+	endSelect.SetSelected(loopEndLabel(current.End))
+
+	updateCountEntry := func(mode player.LoopMode) {
+		if mode == player.LoopTimes || mode == player.LoopPingPong {
+			countEntry.Enable()
+			return
+		}
+		countEntry.Disable()
+	}
+	updateCountEntry(current.Mode)
+
+	apply := func() {
+		mode := loopModeFromLabel(modeSelect.Selected)
+		updateCountEntry(mode)
+		count, _ := strconv.Atoi(strings.TrimSpace(countEntry.Text))
+		end := player.LoopEndLast
+		if endSelect.Selected == loopEndLabel(player.LoopEndFirst) {
+			end = player.LoopEndFirst
+		}
+		win.SetImageLoopSettings(path, player.LoopSettings{Mode: mode, Count: count, End: end})
+	}
+	modeSelect.OnChanged = func(string) { apply() }
+	endSelect.OnChanged = func(string) { apply() }
+	countEntry.OnSubmitted = func(string) { apply() }
+
+	return container.NewVBox(
+		widget.NewLabel("当前图片的动图循环"),
+		container.NewGridWithColumns(3, modeSelect, countEntry, endSelect),
+	)
+}
+
+func newTransitionSetting(win *FloatingWindow) fyne.CanvasObject {
+	if win == nil || win.Player == nil {
+		return widget.NewLabel("无法加载换图过渡设置")
+	}
+
+	labels := make([]string, 0, len(transitionNames))
+	for _, item := range transitionNames {
+		labels = append(labels, item.label)
+	}
+	current := win.TransitionSettings()
+	kindSelect := widget.NewSelect(labels, nil)
+	kindSelect.SetSelected(transitionKindLabel(current.Kind))
+
+	durationLabel := widget.NewLabel("")
+	slider := widget.NewSlider(
+		float64(minTransitionDuration/time.Millisecond),
+		float64(player.MaxTransitionDuration/time.Millisecond),
+	)
+	slider.Step = 50
+	updateLabel := func(ms float64) {
+		durationLabel.SetText(fmt.Sprintf("过渡时长：%d 毫秒", int(ms)))
+	}
+	slider.SetValue(float64(normalizeTransitionDuration(current.Duration) / time.Millisecond))
+	updateLabel(slider.Value)
+
+	apply := func() {
+		win.SetTransitionSettings(player.TransitionSettings{
+			Kind:     transitionKindFromLabel(kindSelect.Selected),
+			Duration: time.Duration(slider.Value) * time.Millisecond,
+		})
+	}
+	kindSelect.OnChanged = func(string) { apply() }
+	slider.OnChanged = updateLabel
+	slider.OnChangeEnded = func(float64) { apply() }
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("换图过渡"), nil, kindSelect),
+		durationLabel,
+		slider,
+	)
+}
+
+func newKenBurnsSetting(win *FloatingWindow) fyne.CanvasObject {
+	if win == nil || win.Player == nil {
+		return widget.NewLabel("无法加载平移缩放设置")
+	}
+
+	current := win.KenBurnsSettings()
+	intensityLabel := widget.NewLabel("")
+	intensity := widget.NewSlider(player.MinKenBurnsIntensity*100, player.MaxKenBurnsIntensity*100)
+	intensity.Step = 1
+	updateIntensity := func(v float64) {
+		intensityLabel.SetText(fmt.Sprintf("最大放大：%d%%", int(v)))
+	}
+	intensity.SetValue(current.Intensity * 100)
+	updateIntensity(intensity.Value)
+
+	cycleLabel := widget.NewLabel("")
+	cycle := widget.NewSlider(player.MinKenBurnsCycle.Seconds(), player.MaxKenBurnsCycle.Seconds())
+	cycle.Step = 1
+	updateCycle := func(v float64) {
+		cycleLabel.SetText(fmt.Sprintf("每段时长：%d 秒", int(v)))
+	}
+	cycle.SetValue(current.Cycle.Seconds())
+	updateCycle(cycle.Value)
+
+	enabled := widget.NewCheck("静态图缓慢平移缩放", nil)
+	enabled.SetChecked(current.Enabled)
+
+	apply := func() {
+		win.SetKenBurnsSettings(player.KenBurnsSettings{
+			Enabled:   enabled.Checked,
+			Intensity: intensity.Value / 100,
+			Cycle:     time.Duration(cycle.Value) * time.Second,
+		})
+	}
+	enabled.OnChanged = func(bool) { apply() }
+	intensity.OnChanged = updateIntensity
+	intensity.OnChangeEnded = func(float64) { apply() }
+	cycle.OnChanged = updateCycle
+	cycle.OnChangeEnded = func(float64) { apply() }
+
+	return container.NewVBox(enabled, intensityLabel, intensity, cycleLabel, cycle)
+}
+
+// filterSlider 是一个带数值标签的滤镜滑块，value 以百分比显示。
+func filterSlider(label string, lo, hi, step float64, format func(float64) string) (*widget.Slider, fyne.CanvasObject) {
+	text := widget.NewLabel("")
+	slider := widget.NewSlider(lo, hi)
+	slider.Step = step
+	update := func(v float64) { text.SetText(label + "：" + format(v)) }
+	update(slider.Value)
+	slider.OnChanged = update
+	return slider, container.NewBorder(nil, nil, text, nil, slider)
+}
+
+func newFilterSetting(win *FloatingWindow) fyne.CanvasObject {
+	if win == nil || win.Player == nil {
+		return widget.NewLabel("无法加载滤镜设置")
+	}
+	path := strings.TrimSpace(win.Player.CurrentPath())
+
+	const (
+		scopeGlobal = "所有图片"
+		scopeImage  = "仅当前图片"
+	)
+	scopes := []string{scopeGlobal}
+	if path != "" {
+		scopes = append(scopes, scopeImage)
+	}
+	scopeSelect := widget.NewSelect(scopes, nil)
+
+	percent := func(v float64) string { return fmt.Sprintf("%+d%%", int(v)) }
+	grayscale := widget.NewCheck("灰度", nil)
+	sepia := widget.NewCheck("怀旧", nil)
+	blur, blurRow := filterSlider("模糊", 0, player.MaxFilterBlur, 0.5, func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	})
+	brightness, brightnessRow := filterSlider("亮度", -100, 100, 5, percent)
+	contrast, contrastRow := filterSlider("对比度", -100, 100, 5, percent)
+	saturation, saturationRow := filterSlider("饱和度", -100, 100, 5, percent)
+	tintLabels := make([]string, 0, len(tintPresets))
+	for _, item := range tintPresets {
+		tintLabels = append(tintLabels, item.label)
+	}
+	tint := widget.NewSelect(tintLabels, nil)
+	tintStrength, tintStrengthRow := filterSlider("叠色强度", 0, 100, 5, func(v float64) string {
+		return fmt.Sprintf("%d%%", int(v))
+	})
+	clearImage := widget.NewButton("当前图片改回跟随全局滤镜", nil)
+
+	loading := false
+	load := func(s player.FilterSettings) {
+		loading = true
+		defer func() { loading = false }()
+		grayscale.SetChecked(s.Grayscale)
+		sepia.SetChecked(s.Sepia)
+		blur.SetValue(s.Blur)
+		brightness.SetValue(s.Brightness * 100)
+		contrast.SetValue(s.Contrast * 100)
+		saturation.SetValue(s.Saturation * 100)
+		tint.SetSelected(tintLabel(s.Tint))
+		tintStrength.SetValue(float64(s.Tint.A) / 255 * 100)
+	}
+	loadScope := func() {
+		if scopeSelect.Selected == scopeImage {
+			s, ok := win.ImageFilters(path)
+			if !ok {
+				s = win.GlobalFilters()
+			}
+			load(s)
+			clearImage.Enable()
+			return
+		}
+		load(win.GlobalFilters())
+		clearImage.Disable()
+	}
+	apply := func() {
+		if loading {
+			return
+		}
+		c := tintFromLabel(tint.Selected)
+		if c != (color.NRGBA{}) {
+			c.A = uint8(tintStrength.Value / 100 * 255)
+		}
+		s := player.FilterSettings{
+			Grayscale:  grayscale.Checked,
+			Sepia:      sepia.Checked,
+			Blur:       blur.Value,
+			Brightness: brightness.Value / 100,
+			Contrast:   contrast.Value / 100,
+			Saturation: saturation.Value / 100,
+			Tint:       c,
+		}
+		if scopeSelect.Selected == scopeImage {
+			win.SetImageFilters(path, &s)
+			return
+		}
+		win.SetGlobalFilters(s)
+	}
+
+	grayscale.OnChanged = func(bool) { apply() }
+	sepia.OnChanged = func(bool) { apply() }
+	tint.OnChanged = func(string) { apply() }
+	for _, slider := range []*widget.Slider{blur, brightness, contrast, saturation, tintStrength} {
+		slider.OnChangeEnded = func(float64) { apply() }
+	}
+	scopeSelect.OnChanged = func(string) { loadScope() }
+	clearImage.OnTapped = func() {
+		win.SetImageFilters(path, nil)
+		loadScope()
+	}
+
+	if _, ok := win.ImageFilters(path); ok && path != "" {
+		scopeSelect.SetSelected(scopeImage)
+	} else {
+		scopeSelect.SetSelected(scopeGlobal)
+	}
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("滤镜"), nil, scopeSelect),
+		container.NewGridWithColumns(2, grayscale, sepia),
+		blurRow,
+		brightnessRow,
+		contrastRow,
+		saturationRow,
+		container.NewBorder(nil, nil, widget.NewLabel("叠色"), nil, tint),
+		tintStrengthRow,
+		clearImage,
+	)
+}
+
+func newMaskSetting(win *FloatingWindow) fyne.CanvasObject {
+	if win == nil || win.Player == nil {
+		return widget.NewLabel("无法加载遮罩设置")
+	}
+
+	current := win.MaskSettings()
+	labels := make([]string, 0, len(maskNames))
+	for _, item := range maskNames {
+		labels = append(labels, item.label)
+	}
+	kindSelect := widget.NewSelect(labels, nil)
+	radius, radiusRow := filterSlider("圆角", 0, player.MaxMaskRadius*100, 1, func(v float64) string {
+		return fmt.Sprintf("%d%%", int(v))
+	})
+	if current.Kind == player.MaskRoundedRect {
+		radius.SetValue(current.Radius * 100)
+	} else {
+		radius.SetValue(defaultMaskRadius * 100)
+	}
+	maskPath := current.Path
+	pathLabel := widget.NewLabel(maskPath)
+	pathLabel.Wrapping = fyne.TextWrapBreak
+	status := widget.NewLabel("")
+	status.Hide()
+
+	apply := func() {
+		s := player.MaskSettings{
+			Kind:   maskKindFromLabel(kindSelect.Selected),
+			Radius: radius.Value / 100,
+			Path:   maskPath,
+		}
+		if s.Kind == player.MaskImage && maskPath == "" {
+			return
+		}
+		if err := win.SetMaskSettings(s); err != nil {
+			status.SetText("遮罩加载失败：" + err.Error())
+			status.Show()
+			return
+		}
+		status.Hide()
+	}
+	choosePNG := widget.NewButton("选择遮罩 PNG", func() {
+		filename, err := sqweek.File().Filter("PNG", "png").Load()
+		if err != nil {
+			return
+		}
+		maskPath = filename
+		pathLabel.SetText(filename)
+		apply()
+	})
+	updateVisibility := func(kind player.MaskKind) {
+		radiusRow.Hide()
+		choosePNG.Hide()
+		pathLabel.Hide()
+		switch kind {
+		case player.MaskRoundedRect:
+			radiusRow.Show()
+		case player.MaskImage:
+			choosePNG.Show()
+			pathLabel.Show()
+		}
+	}
+
+	kindSelect.SetSelected(maskKindLabel(current.Kind))
+	updateVisibility(current.Kind)
+	kindSelect.OnChanged = func(label string) {
+		updateVisibility(maskKindFromLabel(label))
+		apply()
+	}
+	radius.OnChangeEnded = func(float64) { apply() }
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("画面形状"), nil, kindSelect),
+		radiusRow,
+		container.NewHBox(choosePNG),
+		pathLabel,
+		status,
+	)
+}
+
+func newOverlaySetting(win *FloatingWindow) fyne.CanvasObject {
+	if win == nil {
+		return widget.NewLabel("无法加载文字层设置")
+	}
+
+	current := win.OverlaySettings()
+	kindLabels := make([]string, 0, len(overlayKinds))
+	for _, item := range overlayKinds {
+		kindLabels = append(kindLabels, item.label)
+	}
+	cornerLabels := make([]string, 0, len(overlayCorners))
+	for _, item := range overlayCorners {
+		cornerLabels = append(cornerLabels, item.label)
+	}
+	colorLabels := make([]string, 0, len(overlayColors))
+	for _, item := range overlayColors {
+		colorLabels = append(colorLabels, item.label)
+	}
+
+	loading := true
+	kindSelect := widget.NewSelect(kindLabels, nil)
+	textEntry := widget.NewEntry()
+	textEntry.SetPlaceHolder("要显示的文字")
+	textEntry.SetText(current.Text)
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder("2026-01-01 00:00 或 18:00")
+	targetEntry.SetText(current.Target)
+	quoteFile := current.QuoteFile
+	quoteLabel := widget.NewLabel(quoteFile)
+	quoteLabel.Wrapping = fyne.TextWrapBreak
+	fontSize, fontSizeRow := filterSlider("字号", minOverlayFontSize, maxOverlayFontSize, 1, func(v float64) string {
+		return strconv.Itoa(int(v))
+	})
+	fontSize.SetValue(float64(current.FontSize))
+	colorSelect := widget.NewSelect(colorLabels, nil)
+	cornerSelect := widget.NewSelect(cornerLabels, nil)
+	outline := widget.NewCheck("文字描边", nil)
+	outline.SetChecked(current.Outline)
+
+	apply := func() {
+		if loading {
+			return
+		}
+		s := win.OverlaySettings()
+		s.Kind = overlayKindFromLabel(kindSelect.Selected)
+		s.Text = textEntry.Text
+		s.Target = targetEntry.Text
+		s.QuoteFile = quoteFile
+		s.FontSize = float32(fontSize.Value)
+		for _, item := range overlayColors {
+			if item.label == colorSelect.Selected {
+				s.Color = formatTintColor(item.color)
+			}
+		}
+		s.Outline = outline.Checked
+		s.Corner = overlayCornerFromLabel(cornerSelect.Selected)
+		win.SetOverlaySettings(s)
+	}
+	chooseQuotes := widget.NewButton("选择文本文件", func() {
+		filename, err := sqweek.File().Filter("文本文件", "txt").Load()
+		if err != nil {
+			return
+		}
+		quoteFile = filename
+		quoteLabel.SetText(filename)
+		apply()
+	})
+	textRow := container.NewBorder(nil, nil, widget.NewLabel("文字"), nil, textEntry)
+	targetRow := container.NewBorder(nil, nil, widget.NewLabel("目标时间"), nil, targetEntry)
+	updateVisibility := func(kind string) {
+		textRow.Hide()
+		targetRow.Hide()
+		chooseQuotes.Hide()
+		quoteLabel.Hide()
+		switch kind {
+		case overlayKindCaption:
+			textRow.Show()
+		case overlayKindCountdown:
+			targetRow.Show()
+		case overlayKindQuote:
+			chooseQuotes.Show()
+			quoteLabel.Show()
+		}
+	}
+
+	kindSelect.SetSelected(overlayKindLabel(current.Kind))
+	cornerSelect.SetSelected(overlayCornerLabel(current.Corner))
+	colorSelect.SetSelected(colorLabels[0])
+	for _, item := range overlayColors {
+		if formatTintColor(item.color) == current.Color {
+			colorSelect.SetSelected(item.label)
+		}
+	}
+	updateVisibility(current.Kind)
+	loading = false
+
+	kindSelect.OnChanged = func(label string) {
+		updateVisibility(overlayKindFromLabel(label))
+		apply()
+	}
+	textEntry.OnChanged = func(string) { apply() }
+	targetEntry.OnChanged = func(string) { apply() }
+	fontSize.OnChangeEnded = func(float64) { apply() }
+	colorSelect.OnChanged = func(string) { apply() }
+	cornerSelect.OnChanged = func(string) { apply() }
+	outline.OnChanged = func(bool) { apply() }
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("文字层"), nil, kindSelect),
+		textRow,
+		targetRow,
+		container.NewHBox(chooseQuotes),
+		quoteLabel,
+		fontSizeRow,
+		container.NewBorder(nil, nil, widget.NewLabel("颜色"), nil, colorSelect),
+		container.NewBorder(nil, nil, widget.NewLabel("位置"), nil, cornerSelect),
+		outline,
+	)
+}
+
 func openSettingsWindow(a fyne.App, win *FloatingWindow) {
 	if a == nil {
 		return
@@ -487,6 +1211,13 @@ func openSettingsWindow(a fyne.App, win *FloatingWindow) {
 		newReadonlyText(operationGuideText()),
 		widget.NewSeparator(),
 		newImageSourceSetting(win),
+		newImageRotationSetting(win),
+		newImageLoopSetting(win),
+		newTransitionSetting(win),
+		newKenBurnsSetting(win),
+		newFilterSetting(win),
+		newMaskSetting(win),
+		newOverlaySetting(win),
 		widget.NewSeparator(),
 		newLaunchAtStartupSetting(win),
 		newCaptureExcludeSetting(win),
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fyne.io/fyne/v2"
+	"github.com/haua/futu/app/player"
+)
+
+type transformJSON struct {
+	Rotate int  `json:"rotate,omitempty"`
+	Mirror bool `json:"mirror,omitempty"`
+}
+
+func transformToJSON(t player.ImageTransform) *transformJSON {
+	if t.IsIdentity() {
+		return nil
+	}
+	return &transformJSON{Rotate: t.Degrees(), Mirror: t.Mirror}
+}
+
+func transformFromJSON(t *transformJSON) player.ImageTransform {
+	if t == nil {
+		return player.ImageTransform{}
+	}
+	return player.TransformFromDegrees(t.Rotate, t.Mirror)
+}
+
+// ImageTransform 返回某张图的旋转和镜像设置。
+func (f *FloatingWindow) ImageTransform(path string) player.ImageTransform {
+	return transformFromJSON(f.imageSettingsFor(path).Transform)
+}
+
+// SetImageTransform 保存某张图的旋转和镜像，这张图正在播放时立即按新方向重播。
+func (f *FloatingWindow) SetImageTransform(path string, t player.ImageTransform) bool {
+	if !f.updateImageSettings(path, func(settings *imageSettings) {
+		settings.Transform = transformToJSON(t)
+	}) {
+		return false
+	}
+	f.replayIfCurrent(path)
+	return true
+}
+
+// TransformCurrentImage 在当前图片现有的方向上再做一次旋转或翻转。
+func (f *FloatingWindow) TransformCurrentImage(op func(player.ImageTransform) player.ImageTransform) bool {
+	if f == nil || f.Player == nil || op == nil {
+		return false
+	}
+	path := f.Player.CurrentPath()
+	if path == "" {
+		return false
+	}
+	return f.SetImageTransform(path, op(f.ImageTransform(path)))
+}
+
+func (f *FloatingWindow) ResetImageTransform() bool {
+	return f.TransformCurrentImage(func(player.ImageTransform) player.ImageTransform {
+		return player.ImageTransform{}
+	})
+}
+
+// handleTransformKey 处理编辑模式下的旋转翻转按键：R 顺时针、L 逆时针、H 左右翻转、V 上下翻转。
+func (f *FloatingWindow) handleTransformKey(ev *fyne.KeyEvent) bool {
+	if f == nil || ev == nil || !f.IsEditMode() {
+		return false
+	}
+	var op func(player.ImageTransform) player.ImageTransform
+	switch ev.Name {
+	case fyne.KeyR:
+		op = player.ImageTransform.RotateClockwise
+	case fyne.KeyL:
+		op = player.ImageTransform.RotateCounterClockwise
+	case fyne.KeyH:
+		op = player.ImageTransform.FlipHorizontal
+	case fyne.KeyV:
+		op = player.ImageTransform.FlipVertical
+	default:
+		return false
+	}
+	f.TransformCurrentImage(op)
+	return true
+}
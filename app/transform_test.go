@@ -0,0 +1,74 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestTransformJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, want := range []player.ImageTransform{
+		{Quarter: 1},
+		{Quarter: 2, Mirror: true},
+		{Quarter: 3},
+		{Mirror: true},
+	} {
+		if got := transformFromJSON(transformToJSON(want)); got != want {
+			t.Fatalf("round trip = %+v, want %+v", got, want)
+		}
+	}
+	if transformToJSON(player.ImageTransform{Quarter: 4}) != nil {
+		t.Fatalf("identity transform should not be stored")
+	}
+}
+
+func TestImageTransform_PersistsPerImage(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.png")
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	want := player.ImageTransform{Quarter: 1, Mirror: true}
+	fw.SetImageTransform(path, want)
+
+	reloaded := &FloatingWindow{App: a, Player: fw.Player}
+	if got := reloaded.ImageTransform(path); got != want {
+		t.Fatalf("reloaded transform = %+v, want %+v", got, want)
+	}
+	fw.applyImageSettings(path)
+	if got := fw.Player.Transform(); got != want {
+		t.Fatalf("player transform = %+v, want %+v", got, want)
+	}
+	fw.applyImageSettings(filepath.Join(dir, "b.png"))
+	if got := fw.Player.Transform(); !got.IsIdentity() {
+		t.Fatalf("other image should not be rotated, got %+v", got)
+	}
+}
+
+func TestHandleTransformKey(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.editMode.Store(true)
+	if !fw.handleTransformKey(&fyne.KeyEvent{Name: fyne.KeyR}) {
+		t.Fatalf("R should be handled in edit mode")
+	}
+	if fw.handleTransformKey(&fyne.KeyEvent{Name: fyne.KeySpace}) {
+		t.Fatalf("space is not a transform key")
+	}
+	fw.editMode.Store(false)
+	if fw.handleTransformKey(&fyne.KeyEvent{Name: fyne.KeyR}) {
+		t.Fatalf("transform keys only work in edit mode")
+	}
+}
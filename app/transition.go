@@ -0,0 +1,113 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/haua/futu/app/player"
+)
+
+const (
+	transitionKindKey       = "image.transition"
+	transitionDurationMsKey = "image.transition_ms"
+
+	transitionNameNone      = "none"
+	transitionNameCrossfade = "crossfade"
+	transitionNameSlide     = "slide"
+	transitionNameZoom      = "zoom"
+
+	minTransitionDuration = 100 * time.Millisecond
+)
+
+var transitionNames = []struct {
+	kind  player.TransitionKind
+	name  string
+	label string
+}{
+	{player.TransitionNone, transitionNameNone, "无"},
+	{player.TransitionCrossfade, transitionNameCrossfade, "淡入淡出"},
+	{player.TransitionSlide, transitionNameSlide, "滑动"},
+	{player.TransitionZoom, transitionNameZoom, "放大进入"},
+}
+
+func transitionKindFromName(name string) player.TransitionKind {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, item := range transitionNames {
+		if item.name == name {
+			return item.kind
+		}
+	}
+	return player.TransitionNone
+}
+
+func transitionKindName(kind player.TransitionKind) string {
+	for _, item := range transitionNames {
+		if item.kind == kind {
+			return item.name
+		}
+	}
+	return transitionNameNone
+}
+
+func transitionKindLabel(kind player.TransitionKind) string {
+	for _, item := range transitionNames {
+		if item.kind == kind {
+			return item.label
+		}
+	}
+	return transitionNames[0].label
+}
+
+func transitionKindFromLabel(label string) player.TransitionKind {
+	for _, item := range transitionNames {
+		if item.label == label {
+			return item.kind
+		}
+	}
+	return player.TransitionNone
+}
+
+func normalizeTransitionDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return player.DefaultTransitionDuration
+	}
+	if d < minTransitionDuration {
+		return minTransitionDuration
+	}
+	if d > player.MaxTransitionDuration {
+		return player.MaxTransitionDuration
+	}
+	return d
+}
+
+// restoreTransition 读取换图过渡设置，没设置过时直接切换。
+func (f *FloatingWindow) restoreTransition() {
+	if f == nil || f.App == nil || f.Player == nil {
+		return
+	}
+	prefs := f.App.Preferences()
+	kind := transitionKindFromName(prefs.StringWithFallback(transitionKindKey, transitionNameNone))
+	d := time.Duration(prefs.Int(transitionDurationMsKey)) * time.Millisecond
+	f.Player.SetTransition(player.TransitionSettings{Kind: kind, Duration: normalizeTransitionDuration(d)})
+}
+
+func (f *FloatingWindow) TransitionSettings() player.TransitionSettings {
+	if f == nil || f.Player == nil {
+		return player.TransitionSettings{}
+	}
+	return f.Player.Transition()
+}
+
+func (f *FloatingWindow) SetTransitionSettings(s player.TransitionSettings) {
+	if f == nil || f.Player == nil {
+		return
+	}
+	s.Duration = normalizeTransitionDuration(s.Duration)
+	f.Player.SetTransition(s)
+	if f.App == nil {
+		return
+	}
+	prefs := f.App.Preferences()
+	prefs.SetString(transitionKindKey, transitionKindName(s.Kind))
+	prefs.SetInt(transitionDurationMsKey, int(s.Duration/time.Millisecond))
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/haua/futu/app/player"
+)
+
+func TestTransitionKindNames(t *testing.T) {
+	t.Parallel()
+
+	for _, item := range transitionNames {
+		if got := transitionKindFromName(transitionKindName(item.kind)); got != item.kind {
+			t.Fatalf("round trip of %q = %v, want %v", item.name, got, item.kind)
+		}
+		if got := transitionKindFromLabel(transitionKindLabel(item.kind)); got != item.kind {
+			t.Fatalf("label round trip of %q = %v, want %v", item.label, got, item.kind)
+		}
+	}
+	if got := transitionKindFromName("bogus"); got != player.TransitionNone {
+		t.Fatalf("unknown name should fall back to none, got %v", got)
+	}
+}
+
+func TestNormalizeTransitionDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   time.Duration
+		want time.Duration
+	}{
+		{in: 0, want: player.DefaultTransitionDuration},
+		{in: 10 * time.Millisecond, want: minTransitionDuration},
+		{in: 700 * time.Millisecond, want: 700 * time.Millisecond},
+		{in: time.Minute, want: player.MaxTransitionDuration},
+	}
+	for _, tc := range tests {
+		if got := normalizeTransitionDuration(tc.in); got != tc.want {
+			t.Fatalf("normalizeTransitionDuration(%v) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestTransitionSettings_PersistAndRestore(t *testing.T) {
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	w := a.NewWindow("test")
+	t.Cleanup(w.Close)
+
+	fw := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	fw.restoreTransition()
+	if got := fw.TransitionSettings(); got.Kind != player.TransitionNone || got.Duration != player.DefaultTransitionDuration {
+		t.Fatalf("default transition = %+v, want none with default duration", got)
+	}
+
+	fw.SetTransitionSettings(player.TransitionSettings{Kind: player.TransitionSlide, Duration: 800 * time.Millisecond})
+
+	restored := &FloatingWindow{App: a, Player: player.NewPlayer(a, w)}
+	restored.restoreTransition()
+	if got := restored.TransitionSettings(); got.Kind != player.TransitionSlide || got.Duration != 800*time.Millisecond {
+		t.Fatalf("restored transition = %+v, want slide 800ms", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/systray"
+	"github.com/haua/futu/app/player"
 	sqweek "github.com/sqweek/dialog"
 )
 
@@ -34,8 +35,91 @@ func modeMenuLabel(isEdit bool) string {
 	return modeHintText(isEdit)
 }
 
+func playbackPauseMenuLabel(paused bool) string {
+	if paused {
+		return "\u7ee7\u7eed\u64ad\u653e"
+	}
+	return "\u6682\u505c"
+}
+
+// newImageAdjustMenu 构建“调整图片”子菜单，ensureEditMode 在需要时切换到编辑模式。
+func newImageAdjustMenu(win *FloatingWindow, ensureEditMode func()) *fyne.MenuItem {
+	cropItem := fyne.NewMenuItem("\u88c1\u526a\u5f53\u524d\u56fe\u7247", func() {
+		fyne.Do(func() {
+			ensureEditMode()
+			win.StartCropSelection()
+		})
+	})
+	resetCropItem := fyne.NewMenuItem("\u8fd8\u539f\u88c1\u526a", func() {
+		win.ResetImageCrop()
+	})
+
+	transformItem := func(label string, op func(player.ImageTransform) player.ImageTransform) *fyne.MenuItem {
+		return fyne.NewMenuItem(label, func() {
+			win.TransformCurrentImage(op)
+		})
+	}
+
+	item := fyne.NewMenuItem("\u8c03\u6574\u56fe\u7247", nil)
+	item.ChildMenu = fyne.NewMenu("",
+		cropItem,
+		resetCropItem,
+		fyne.NewMenuItemSeparator(),
+		transformItem("\u987a\u65f6\u9488\u65cb\u8f6c 90\u00b0", player.ImageTransform.RotateClockwise),
+		transformItem("\u9006\u65f6\u9488\u65cb\u8f6c 90\u00b0", player.ImageTransform.RotateCounterClockwise),
+		transformItem("\u6c34\u5e73\u7ffb\u8f6c", player.ImageTransform.FlipHorizontal),
+		transformItem("\u5782\u76f4\u7ffb\u8f6c", player.ImageTransform.FlipVertical),
+		fyne.NewMenuItem("\u8fd8\u539f\u65cb\u8f6c\u548c\u7ffb\u8f6c", func() {
+			win.ResetImageTransform()
+		}),
+	)
+	return item
+}
+
+func newPlaybackMenu(win *FloatingWindow, refresh func()) *fyne.MenuItem {
+	pauseItem := fyne.NewMenuItem(playbackPauseMenuLabel(win.IsPlaybackPaused()), func() {
+		win.TogglePlaybackPaused()
+	})
+	prevItem := fyne.NewMenuItem("\u4e0a\u4e00\u5e27", func() {
+		win.StepPlayback(false)
+	})
+	nextItem := fyne.NewMenuItem("\u4e0b\u4e00\u5e27", func() {
+		win.StepPlayback(true)
+	})
+
+	speedItems := make([]*fyne.MenuItem, 0, len(playbackSpeedPresets))
+	for _, speed := range playbackSpeedPresets {
+		speed := speed
+		item := fyne.NewMenuItem(playbackSpeedLabel(speed), func() {
+			win.SetPlaybackSpeed(speed)
+		})
+		speedItems = append(speedItems, item)
+	}
+	speedItem := fyne.NewMenuItem("\u901f\u5ea6", nil)
+	speedItem.ChildMenu = fyne.NewMenu("", speedItems...)
+
+	update := func() {
+		pauseItem.Label = playbackPauseMenuLabel(win.IsPlaybackPaused())
+		current := win.PlaybackSpeed()
+		for i, item := range speedItems {
+			item.Checked = samePlaybackSpeed(playbackSpeedPresets[i], current)
+		}
+	}
+	update()
+	win.onPlaybackChanged = func() {
+		update()
+		if refresh != nil {
+			refresh()
+		}
+	}
+
+	item := fyne.NewMenuItem("\u64ad\u653e\u63a7\u5236", nil)
+	item.ChildMenu = fyne.NewMenu("", pauseItem, prevItem, nextItem, fyne.NewMenuItemSeparator(), speedItem)
+	return item
+}
+
 func imageFileFilters() (string, []string) {
-	allow := []string{"png", "jpeg", "jpg", "gif", "webp"}
+	allow := player.SupportedExtensions()
 	return strings.Join(allow, ","), allow
 }
 
@@ -118,6 +202,16 @@ func SetupTray(a fyne.App, win *FloatingWindow) {
 		modeItem,
 		topMostItem,
 		windowVisibilityItem,
+		newPlaybackMenu(win, func() {
+			fyne.Do(func() {
+				desk.SetSystemTrayMenu(menu)
+			})
+		}),
+		newImageAdjustMenu(win, func() {
+			if !win.IsEditMode() {
+				refreshTrayState(win.ToggleEditMode())
+			}
+		}),
 		fyne.NewMenuItem("\u66f4\u6362\u56fe\u7247", func() {
 			// Use native file picker for better UX than Fyne file dialog.
 			pickAndPlayImage(win)
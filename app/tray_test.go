@@ -46,10 +46,10 @@ func TestImageFileFilters(t *testing.T) {
 	t.Parallel()
 
 	name, allow := imageFileFilters()
-	if name != "png,jpeg,jpg,gif,webp" {
+	if name != "png,jpeg,jpg,gif,webp,bmp,tiff,tif,svg" {
 		t.Fatalf("filter name = %q", name)
 	}
-	want := []string{"png", "jpeg", "jpg", "gif", "webp"}
+	want := []string{"png", "jpeg", "jpg", "gif", "webp", "bmp", "tiff", "tif", "svg"}
 	if len(allow) != len(want) {
 		t.Fatalf("allow length = %d, want %d", len(allow), len(want))
 	}